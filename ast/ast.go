@@ -1,5 +1,4 @@
 package ast
- 
 
 import (
 	"bytes"
@@ -10,6 +9,10 @@ import (
 type Node interface {
 	TokenLiteral() string
 	String() string
+	// Pos and End return the source span of the node; End is the
+	// position just past its last character.
+	Pos() token.Position
+	End() token.Position
 }
 
 type Statement interface {
@@ -24,6 +27,31 @@ type Expression interface {
 
 type Program struct {
 	Statements []Statement
+	// Comments holds every comment in the source, in order. They are
+	// not attached to the statements.
+	Comments []*Comment
+}
+
+// Comment is a "//" or "/* */" comment, including its delimiters.
+type Comment struct {
+	Token token.Token
+}
+
+func (c *Comment) Pos() token.Position {
+	return c.Token.Pos
+}
+func (c *Comment) End() token.Position {
+	return c.Token.End
+}
+
+// Text returns the comment without its delimiters.
+func (c *Comment) Text() string {
+
+	text := c.Token.Literal
+	if strings.HasPrefix(text, "//") {
+		return text[2:]
+	}
+	return strings.TrimSuffix(text[2:], "*/")
 }
 
 func (p *Program) TokenLiteral() string {
@@ -44,6 +72,20 @@ func (p *Program) String() string {
 	return out.String()
 }
 
+func (p *Program) Pos() token.Position {
+	if len(p.Statements) > 0 {
+		return p.Statements[0].Pos()
+	}
+	return token.Position{}
+}
+
+func (p *Program) End() token.Position {
+	if len(p.Statements) > 0 {
+		return p.Statements[len(p.Statements)-1].End()
+	}
+	return token.Position{}
+}
+
 type LetStatement struct {
 	Token token.Token
 	Name  *Identifier
@@ -54,6 +96,15 @@ func (ls *LetStatement) TokenLiteral() string {
 	return ls.Token.Literal
 }
 func (ls *LetStatement) statementNode() {}
+func (ls *LetStatement) Pos() token.Position {
+	return ls.Token.Pos
+}
+func (ls *LetStatement) End() token.Position {
+	if ls.Value != nil {
+		return ls.Value.End()
+	}
+	return ls.Name.End()
+}
 
 func (ls *LetStatement) String() string {
 
@@ -79,6 +130,12 @@ func (i *Identifier) expressionNode() {}
 func (i *Identifier) TokenLiteral() string {
 	return i.Token.Literal
 }
+func (i *Identifier) Pos() token.Position {
+	return i.Token.Pos
+}
+func (i *Identifier) End() token.Position {
+	return i.Token.End
+}
 
 func (i *Identifier) String() string {
 	return i.Value
@@ -94,6 +151,15 @@ func (rs *ReturnStatement) TokenLiteral() string {
 }
 
 func (rs *ReturnStatement) statementNode() {}
+func (rs *ReturnStatement) Pos() token.Position {
+	return rs.Token.Pos
+}
+func (rs *ReturnStatement) End() token.Position {
+	if rs.ReturnValue != nil {
+		return rs.ReturnValue.End()
+	}
+	return rs.Token.End
+}
 
 func (rs *ReturnStatement) String() string {
 
@@ -118,6 +184,15 @@ func (exs *ExpressionStatement) TokenLiteral() string {
 }
 
 func (exs *ExpressionStatement) statementNode() {}
+func (exs *ExpressionStatement) Pos() token.Position {
+	return exs.Token.Pos
+}
+func (exs *ExpressionStatement) End() token.Position {
+	if exs.Expression != nil {
+		return exs.Expression.End()
+	}
+	return exs.Token.End
+}
 
 func (exs *ExpressionStatement) String() string {
 	if exs.Expression != nil {
@@ -135,10 +210,35 @@ func (il *IntegerLiteral) expressionNode() {}
 func (il *IntegerLiteral) TokenLiteral() string {
 	return il.Token.Literal
 }
+func (il *IntegerLiteral) Pos() token.Position {
+	return il.Token.Pos
+}
+func (il *IntegerLiteral) End() token.Position {
+	return il.Token.End
+}
 func (il *IntegerLiteral) String() string {
 	return il.Token.Literal
 }
 
+type FloatLiteral struct {
+	Token token.Token
+	Value float64
+}
+
+func (fl *FloatLiteral) expressionNode() {}
+func (fl *FloatLiteral) TokenLiteral() string {
+	return fl.Token.Literal
+}
+func (fl *FloatLiteral) Pos() token.Position {
+	return fl.Token.Pos
+}
+func (fl *FloatLiteral) End() token.Position {
+	return fl.Token.End
+}
+func (fl *FloatLiteral) String() string {
+	return fl.Token.Literal
+}
+
 type StringLiteral struct {
 	Token token.Token
 	Value string
@@ -147,6 +247,12 @@ type StringLiteral struct {
 func (st *StringLiteral) TokenLiteral() string {
 	return st.Token.Literal
 }
+func (st *StringLiteral) Pos() token.Position {
+	return st.Token.Pos
+}
+func (st *StringLiteral) End() token.Position {
+	return st.Token.End
+}
 
 func (st *StringLiteral) expressionNode() {}
 func (st *StringLiteral) String() string {
@@ -163,6 +269,12 @@ func (pe *PrefixExpression) expressionNode() {}
 func (pe *PrefixExpression) TokenLiteral() string {
 	return pe.Token.Literal
 }
+func (pe *PrefixExpression) Pos() token.Position {
+	return pe.Token.Pos
+}
+func (pe *PrefixExpression) End() token.Position {
+	return pe.Right.End()
+}
 
 func (pe *PrefixExpression) String() string {
 
@@ -186,6 +298,12 @@ type InfixExpression struct {
 func (ie *InfixExpression) TokenLiteral() string {
 	return ie.Token.Literal
 }
+func (ie *InfixExpression) Pos() token.Position {
+	return ie.Left.Pos()
+}
+func (ie *InfixExpression) End() token.Position {
+	return ie.Right.End()
+}
 
 func (ie *InfixExpression) expressionNode() {}
 
@@ -202,6 +320,38 @@ func (ie *InfixExpression) String() string {
 	return out.String()
 }
 
+// AssignExpression stores Value into Target, an identifier or an index
+// expression. Operator is "=" or a compound form such as "+=".
+type AssignExpression struct {
+	Token    token.Token
+	Target   Expression
+	Operator string
+	Value    Expression
+}
+
+func (ae *AssignExpression) TokenLiteral() string {
+	return ae.Token.Literal
+}
+func (ae *AssignExpression) Pos() token.Position {
+	return ae.Target.Pos()
+}
+func (ae *AssignExpression) End() token.Position {
+	return ae.Value.End()
+}
+
+func (ae *AssignExpression) expressionNode() {}
+
+func (ae *AssignExpression) String() string {
+
+	var out bytes.Buffer
+
+	out.WriteString(ae.Target.String())
+	out.WriteString(" " + ae.Operator + " ")
+	out.WriteString(ae.Value.String())
+
+	return out.String()
+}
+
 type Boolean struct {
 	Token token.Token
 	Value bool
@@ -211,10 +361,34 @@ func (b *Boolean) expressionNode() {}
 func (b *Boolean) TokenLiteral() string {
 	return b.Token.Literal
 }
+func (b *Boolean) Pos() token.Position {
+	return b.Token.Pos
+}
+func (b *Boolean) End() token.Position {
+	return b.Token.End
+}
 func (b *Boolean) String() string {
 	return b.Token.Literal
 }
 
+type NullLiteral struct {
+	Token token.Token
+}
+
+func (n *NullLiteral) expressionNode() {}
+func (n *NullLiteral) TokenLiteral() string {
+	return n.Token.Literal
+}
+func (n *NullLiteral) Pos() token.Position {
+	return n.Token.Pos
+}
+func (n *NullLiteral) End() token.Position {
+	return n.Token.End
+}
+func (n *NullLiteral) String() string {
+	return n.Token.Literal
+}
+
 type IfExpression struct {
 	Token       token.Token
 	Condition   Expression
@@ -226,6 +400,15 @@ func (ie *IfExpression) expressionNode() {}
 func (ie *IfExpression) TokenLiteral() string {
 	return ie.Token.Literal
 }
+func (ie *IfExpression) Pos() token.Position {
+	return ie.Token.Pos
+}
+func (ie *IfExpression) End() token.Position {
+	if ie.Alternative != nil {
+		return ie.Alternative.End()
+	}
+	return ie.Consequence.End()
+}
 func (ie *IfExpression) String() string {
 	var out bytes.Buffer
 
@@ -249,12 +432,19 @@ func (ie *IfExpression) String() string {
 type BlockStatement struct {
 	Token      token.Token
 	Statements []Statement
+	Rbrace     token.Token
 }
 
 func (bs *BlockStatement) statementNode() {}
 func (bs *BlockStatement) TokenLiteral() string {
 	return bs.Token.Literal
 }
+func (bs *BlockStatement) Pos() token.Position {
+	return bs.Token.Pos
+}
+func (bs *BlockStatement) End() token.Position {
+	return bs.Rbrace.End
+}
 func (bs *BlockStatement) String() string {
 	var out bytes.Buffer
 	for _, s := range bs.Statements {
@@ -267,12 +457,21 @@ type FunctionLiteral struct {
 	Token      token.Token
 	Parameters []*Identifier
 	Body       *BlockStatement
+	// Name is the name the literal is bound to by a let statement, if
+	// any. It is only used for diagnostics.
+	Name string
 }
 
 func (fl *FunctionLiteral) expressionNode() {}
 func (fl *FunctionLiteral) TokenLiteral() string {
 	return fl.Token.Literal
 }
+func (fl *FunctionLiteral) Pos() token.Position {
+	return fl.Token.Pos
+}
+func (fl *FunctionLiteral) End() token.Position {
+	return fl.Body.End()
+}
 
 func (fl *FunctionLiteral) String() string {
 	var out bytes.Buffer
@@ -294,11 +493,24 @@ type CallExpression struct {
 	Token     token.Token
 	Function  Expression
 	Arguments []Expression
+	Rparen    token.Token
+	// Optional is set for f?(...), which gives null without calling
+	// anything when f is null.
+	Optional bool
+	// Tail is set for a call whose value is the value of the function
+	// it's in, so the call can reuse the caller's frame.
+	Tail bool
 }
 
 func (ce *CallExpression) TokenLiteral() string {
 	return ce.Token.Literal
 }
+func (ce *CallExpression) Pos() token.Position {
+	return ce.Function.Pos()
+}
+func (ce *CallExpression) End() token.Position {
+	return ce.Rparen.End
+}
 func (ce *CallExpression) expressionNode() {}
 func (ce *CallExpression) String() string {
 	var out bytes.Buffer
@@ -309,6 +521,9 @@ func (ce *CallExpression) String() string {
 	}
 
 	out.WriteString(ce.Function.String())
+	if ce.Optional {
+		out.WriteString("?")
+	}
 	out.WriteString("(")
 	out.WriteString(strings.Join(args, ", "))
 	out.WriteString(")")
@@ -316,15 +531,58 @@ func (ce *CallExpression) String() string {
 	return out.String()
 }
 
+// InterpolatedString is a string with embedded expressions. Parts
+// alternates between the text pieces, as *StringLiteral, and the
+// embedded expressions, so it starts and ends with text, which may be
+// empty.
+type InterpolatedString struct {
+	Token   token.Token // the INTERP_START token
+	Parts   []Expression
+	Closing token.Token // the INTERP_END token
+}
+
+func (is *InterpolatedString) expressionNode() {}
+func (is *InterpolatedString) TokenLiteral() string {
+	return is.Token.Literal
+}
+func (is *InterpolatedString) Pos() token.Position {
+	return is.Token.Pos
+}
+func (is *InterpolatedString) End() token.Position {
+	return is.Closing.End
+}
+func (is *InterpolatedString) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(`"`)
+	for i, part := range is.Parts {
+		if i%2 == 0 {
+			out.WriteString(part.String())
+		} else {
+			out.WriteString("${" + part.String() + "}")
+		}
+	}
+	out.WriteString(`"`)
+
+	return out.String()
+}
+
 type ArrayLiteral struct {
 	Token    token.Token
 	Elements []Expression
+	Rbracket token.Token
 }
 
 func (al *ArrayLiteral) expressionNode() {}
 func (al *ArrayLiteral) TokenLiteral() string {
 	return al.Token.Literal
 }
+func (al *ArrayLiteral) Pos() token.Position {
+	return al.Token.Pos
+}
+func (al *ArrayLiteral) End() token.Position {
+	return al.Rbracket.End
+}
 func (al *ArrayLiteral) String() string {
 	var out bytes.Buffer
 
@@ -340,20 +598,33 @@ func (al *ArrayLiteral) String() string {
 }
 
 type IndexExpression struct {
-	Token token.Token
-	Left  Expression
-	Index Expression
+	Token    token.Token
+	Left     Expression
+	Index    Expression
+	Rbracket token.Token
+	// Optional is set for x?[...], which gives null without evaluating
+	// the index when x is null.
+	Optional bool
 }
 
 func (ie *IndexExpression) expressionNode() {}
 func (ie *IndexExpression) TokenLiteral() string {
 	return ie.Token.Literal
 }
+func (ie *IndexExpression) Pos() token.Position {
+	return ie.Left.Pos()
+}
+func (ie *IndexExpression) End() token.Position {
+	return ie.Rbracket.End
+}
 func (ie *IndexExpression) String() string {
 	var out bytes.Buffer
 
 	out.WriteString("(")
 	out.WriteString(ie.Left.String())
+	if ie.Optional {
+		out.WriteString("?")
+	}
 	out.WriteString("[")
 	out.WriteString(ie.Index.String())
 	out.WriteString("]")
@@ -362,14 +633,64 @@ func (ie *IndexExpression) String() string {
 	return out.String()
 }
 
+// SliceExpression is left[low:high]. Low and High are nil when they
+// are left out.
+type SliceExpression struct {
+	Token    token.Token
+	Left     Expression
+	Low      Expression
+	High     Expression
+	Rbracket token.Token
+	Optional bool
+}
+
+func (se *SliceExpression) expressionNode() {}
+func (se *SliceExpression) TokenLiteral() string {
+	return se.Token.Literal
+}
+func (se *SliceExpression) Pos() token.Position {
+	return se.Left.Pos()
+}
+func (se *SliceExpression) End() token.Position {
+	return se.Rbracket.End
+}
+func (se *SliceExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(se.Left.String())
+	if se.Optional {
+		out.WriteString("?")
+	}
+	out.WriteString("[")
+	if se.Low != nil {
+		out.WriteString(se.Low.String())
+	}
+	out.WriteString(":")
+	if se.High != nil {
+		out.WriteString(se.High.String())
+	}
+	out.WriteString("]")
+	out.WriteString(")")
+
+	return out.String()
+}
+
 type HashLiteral struct {
-	Token token.Token
-	Pairs map[Expression]Expression
+	Token  token.Token
+	Pairs  map[Expression]Expression
+	Rbrace token.Token
 }
 
 func (hl *HashLiteral) TokenLiteral() string {
 	return hl.Token.Literal
 }
+func (hl *HashLiteral) Pos() token.Position {
+	return hl.Token.Pos
+}
+func (hl *HashLiteral) End() token.Position {
+	return hl.Rbrace.End
+}
 func (hl *HashLiteral) expressionNode() {}
 func (hl *HashLiteral) String() string {
 	var out bytes.Buffer
@@ -385,3 +706,145 @@ func (hl *HashLiteral) String() string {
 
 	return out.String()
 }
+
+type WhileStatement struct {
+	Token     token.Token
+	Condition Expression
+	Body      *BlockStatement
+}
+
+func (ws *WhileStatement) statementNode() {}
+func (ws *WhileStatement) TokenLiteral() string {
+	return ws.Token.Literal
+}
+func (ws *WhileStatement) Pos() token.Position {
+	return ws.Token.Pos
+}
+func (ws *WhileStatement) End() token.Position {
+	return ws.Body.End()
+}
+func (ws *WhileStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(ws.TokenLiteral())
+	out.WriteString(" (")
+	out.WriteString(ws.Condition.String())
+	out.WriteString(") { ")
+	out.WriteString(ws.Body.String())
+	out.WriteString(" }")
+
+	return out.String()
+}
+
+// ForStatement is a C-style loop. Init, Condition and Post are all
+// optional.
+type ForStatement struct {
+	Token     token.Token
+	Init      Statement
+	Condition Expression
+	Post      Statement
+	Body      *BlockStatement
+}
+
+func (fs *ForStatement) statementNode() {}
+func (fs *ForStatement) TokenLiteral() string {
+	return fs.Token.Literal
+}
+func (fs *ForStatement) Pos() token.Position {
+	return fs.Token.Pos
+}
+func (fs *ForStatement) End() token.Position {
+	return fs.Body.End()
+}
+func (fs *ForStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(fs.TokenLiteral())
+	out.WriteString(" (")
+	if fs.Init != nil {
+		out.WriteString(strings.TrimSuffix(fs.Init.String(), ";"))
+	}
+	out.WriteString("; ")
+	if fs.Condition != nil {
+		out.WriteString(fs.Condition.String())
+	}
+	out.WriteString("; ")
+	if fs.Post != nil {
+		out.WriteString(strings.TrimSuffix(fs.Post.String(), ";"))
+	}
+	out.WriteString(") { ")
+	out.WriteString(fs.Body.String())
+	out.WriteString(" }")
+
+	return out.String()
+}
+
+// ForInStatement runs Body once for every element of Iterable, bound
+// to Variable.
+type ForInStatement struct {
+	Token    token.Token
+	Variable *Identifier
+	Iterable Expression
+	Body     *BlockStatement
+}
+
+func (fs *ForInStatement) statementNode() {}
+func (fs *ForInStatement) TokenLiteral() string {
+	return fs.Token.Literal
+}
+func (fs *ForInStatement) Pos() token.Position {
+	return fs.Token.Pos
+}
+func (fs *ForInStatement) End() token.Position {
+	return fs.Body.End()
+}
+func (fs *ForInStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(fs.TokenLiteral())
+	out.WriteString(" (")
+	out.WriteString(fs.Variable.String())
+	out.WriteString(" in ")
+	out.WriteString(fs.Iterable.String())
+	out.WriteString(") { ")
+	out.WriteString(fs.Body.String())
+	out.WriteString(" }")
+
+	return out.String()
+}
+
+type BreakStatement struct {
+	Token token.Token
+}
+
+func (bs *BreakStatement) statementNode() {}
+func (bs *BreakStatement) TokenLiteral() string {
+	return bs.Token.Literal
+}
+func (bs *BreakStatement) Pos() token.Position {
+	return bs.Token.Pos
+}
+func (bs *BreakStatement) End() token.Position {
+	return bs.Token.End
+}
+func (bs *BreakStatement) String() string {
+	return bs.TokenLiteral() + ";"
+}
+
+type ContinueStatement struct {
+	Token token.Token
+}
+
+func (cs *ContinueStatement) statementNode() {}
+func (cs *ContinueStatement) TokenLiteral() string {
+	return cs.Token.Literal
+}
+func (cs *ContinueStatement) Pos() token.Position {
+	return cs.Token.Pos
+}
+func (cs *ContinueStatement) End() token.Position {
+	return cs.Token.End
+}
+func (cs *ContinueStatement) String() string {
+	return cs.TokenLiteral() + ";"
+}
@@ -0,0 +1,296 @@
+package camel
+
+import (
+	"camel/eval"
+	"camel/object"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var (
+	objectType = reflect.TypeOf((*object.Object)(nil)).Elem()
+	errorType  = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// NewBuiltin wraps the Go function fn as a camel builtin. name is used
+// in the errors the builtin reports.
+//
+// Arguments are converted to the types of fn's parameters and checked
+// before fn is called: integers to any integer type they fit in,
+// integers and floats to float types, strings, booleans, arrays to
+// slices and hashes to maps. An object.Object parameter, or one of the
+// object types such as *object.Function, receives the camel value
+// itself, and an any parameter receives it converted as by
+// Interpreter.FromObject, its functions calling back into the
+// evaluator that called the builtin. Null converts to the zero value
+// of pointers, slices, maps, channels, functions and interfaces. fn
+// may be variadic.
+//
+// fn may return nothing, a value, an error, or a value and an error.
+// Values are converted with ToObject; nothing gives null. A non-nil
+// error is raised in the script as a runtime error with the error's
+// message. An *Error, such as one from calling a script function
+// passed in, is raised as it is.
+//
+// An object.BuiltinFunction or *object.Builtin is used as it is.
+func NewBuiltin(name string, fn any) (*object.Builtin, error) {
+
+	switch fn := fn.(type) {
+	case *object.Builtin:
+		return fn, nil
+	case object.BuiltinFunction:
+		return &object.Builtin{Fn: fn}, nil
+	case func(object.Evaluator, ...object.Object) object.Object:
+		return &object.Builtin{Fn: fn}, nil
+	}
+
+	rv := reflect.ValueOf(fn)
+	if rv.Kind() != reflect.Func || rv.IsNil() {
+		return nil, fmt.Errorf("camel: builtin %s: %T is not a function", name, fn)
+	}
+
+	typ := rv.Type()
+	switch {
+	case typ.NumOut() > 2,
+		typ.NumOut() == 2 && typ.Out(1) != errorType:
+		return nil, fmt.Errorf("camel: builtin %s: results must be (), (T), "+
+			"(error) or (T, error), got %s", name, typ)
+	}
+
+	return &object.Builtin{
+		Fn: func(e object.Evaluator, args ...object.Object) object.Object {
+			in, errObj := convertArgs(e, name, typ, args)
+			if errObj != nil {
+				return errObj
+			}
+			return convertResults(name, rv.Call(in))
+		},
+	}, nil
+}
+
+func convertArgs(
+	e object.Evaluator,
+	name string,
+	typ reflect.Type,
+	args []object.Object,
+) ([]reflect.Value, *object.Error) {
+
+	params := typ.NumIn()
+	if typ.IsVariadic() && len(args) < params-1 {
+		return nil, runtimeError("wrong number of arguments, "+
+			"expected:at least %d, got: %d", params-1, len(args))
+	}
+	if !typ.IsVariadic() && len(args) != params {
+		return nil, runtimeError("wrong number of arguments, "+
+			"expected:%d, got: %d", params, len(args))
+	}
+
+	in := make([]reflect.Value, len(args))
+	for i, arg := range args {
+		var paramType reflect.Type
+		if typ.IsVariadic() && i >= params-1 {
+			paramType = typ.In(params - 1).Elem()
+		} else {
+			paramType = typ.In(i)
+		}
+
+		value, err := fromObjectTo(e, arg, paramType)
+		if conv, ok := err.(*conversionError); ok {
+			return nil, runtimeError("argument %d to %s%s", i+1, name, conv)
+		}
+		if err != nil {
+			return nil, runtimeError("argument %d to %s: %s", i+1, name, err)
+		}
+		in[i] = value
+	}
+	return in, nil
+}
+
+func convertResults(name string, out []reflect.Value) object.Object {
+
+	if len(out) > 0 && out[len(out)-1].Type() == errorType {
+		if err, _ := out[len(out)-1].Interface().(error); err != nil {
+			if scriptErr, ok := err.(*Error); ok {
+				return scriptErr.Err
+			}
+			return runtimeError("%s", err)
+		}
+		out = out[:len(out)-1]
+	}
+	if len(out) == 0 {
+		return eval.NULL
+	}
+
+	obj, err := ToObject(out[0].Interface())
+	if err != nil {
+		return runtimeError("result of %s: %s", name, err)
+	}
+	return obj
+}
+
+// conversionError tells why a camel value doesn't fit a Go type. path
+// locates the value inside the argument, such as [2] for the third
+// element of an array.
+type conversionError struct {
+	path string
+	want string
+	got  object.ObjectType
+}
+
+func (e *conversionError) Error() string {
+	return fmt.Sprintf("%s must be %s, got %s", e.path, e.want, e.got)
+}
+
+// fromObjectTo converts obj to a Go value of type typ. Functions
+// converted to any are called by e.
+func fromObjectTo(e object.Evaluator, obj object.Object, typ reflect.Type) (reflect.Value, error) {
+
+	mismatch := &conversionError{want: typeName(typ), got: obj.Type()}
+
+	if obj == eval.NULL && typ != objectType {
+		switch typ.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Map, reflect.Interface,
+			reflect.Chan, reflect.Func:
+			return reflect.Zero(typ), nil
+		}
+		return reflect.Value{}, mismatch
+	}
+	if typ.Kind() == reflect.Interface && typ.NumMethod() == 0 {
+		value := reflect.New(typ).Elem()
+		value.Set(reflect.ValueOf(fromObject(e, obj, map[object.Object]any{})))
+		return value, nil
+	}
+	if reflect.TypeOf(obj) == typ ||
+		typ.Kind() == reflect.Interface && reflect.TypeOf(obj).Implements(typ) {
+		return reflect.ValueOf(obj), nil
+	}
+
+	value := reflect.New(typ).Elem()
+
+	switch typ.Kind() {
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, ok := obj.(*object.Integer)
+		if !ok {
+			return reflect.Value{}, mismatch
+		}
+		if value.OverflowInt(n.Value) {
+			return reflect.Value{}, fmt.Errorf("%d overflows %s", n.Value, typ)
+		}
+		value.SetInt(n.Value)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64, reflect.Uintptr:
+		n, ok := obj.(*object.Integer)
+		if !ok {
+			return reflect.Value{}, mismatch
+		}
+		if n.Value < 0 || value.OverflowUint(uint64(n.Value)) {
+			return reflect.Value{}, fmt.Errorf("%d overflows %s", n.Value, typ)
+		}
+		value.SetUint(uint64(n.Value))
+
+	case reflect.Float32, reflect.Float64:
+		switch n := obj.(type) {
+		case *object.Integer:
+			value.SetFloat(float64(n.Value))
+		case *object.Float:
+			value.SetFloat(n.Value)
+		default:
+			return reflect.Value{}, mismatch
+		}
+
+	case reflect.String:
+		s, ok := obj.(*object.String)
+		if !ok {
+			return reflect.Value{}, mismatch
+		}
+		value.SetString(s.Value)
+
+	case reflect.Bool:
+		b, ok := obj.(*object.Boolean)
+		if !ok {
+			return reflect.Value{}, mismatch
+		}
+		value.SetBool(b.Value)
+
+	case reflect.Slice:
+		arr, ok := obj.(*object.Array)
+		if !ok {
+			return reflect.Value{}, mismatch
+		}
+		value.Set(reflect.MakeSlice(typ, len(arr.Elements), len(arr.Elements)))
+		for i, elem := range arr.Elements {
+			v, err := fromObjectTo(e, elem, typ.Elem())
+			if err != nil {
+				return reflect.Value{}, prefixPath(fmt.Sprintf("[%d]", i), err)
+			}
+			value.Index(i).Set(v)
+		}
+
+	case reflect.Map:
+		hash, ok := obj.(*object.Hash)
+		if !ok {
+			return reflect.Value{}, mismatch
+		}
+		value.Set(reflect.MakeMapWithSize(typ, len(hash.Pairs)))
+		for _, pair := range hash.SortedPairs() {
+			k, err := fromObjectTo(e, pair.Key, typ.Key())
+			if err != nil {
+				return reflect.Value{}, prefixPath(" key", err)
+			}
+			v, err := fromObjectTo(e, pair.Value, typ.Elem())
+			if err != nil {
+				return reflect.Value{}, prefixPath("["+pair.Key.Inspect()+"]", err)
+			}
+			value.SetMapIndex(k, v)
+		}
+
+	default:
+		return reflect.Value{}, mismatch
+	}
+
+	return value, nil
+}
+
+func prefixPath(prefix string, err error) error {
+	if conv, ok := err.(*conversionError); ok {
+		return &conversionError{path: prefix + conv.path, want: conv.want, got: conv.got}
+	}
+	return err
+}
+
+// typeName describes the camel values a Go type accepts.
+func typeName(typ reflect.Type) string {
+
+	if typ.Implements(objectType) && typ.Kind() == reflect.Pointer {
+		obj := reflect.Zero(typ).Interface().(object.Object)
+		return strings.ToLower(string(obj.Type()))
+	}
+
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64, reflect.Uintptr:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Slice:
+		return "array"
+	case reflect.Map:
+		return "hash"
+	}
+	return typ.String()
+}
+
+func runtimeError(format string, a ...any) *object.Error {
+	return &object.Error{
+		Kind:    object.RUNTIME_ERROR,
+		Message: fmt.Sprintf(format, a...),
+	}
+}
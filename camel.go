@@ -0,0 +1,206 @@
+// Package camel runs camel programs from Go. An Interpreter keeps its
+// global variables between calls to Eval, so a host can define values
+// and functions, run scripts, and call the functions they define.
+package camel
+
+import (
+	"camel/eval"
+	"camel/lexer"
+	"camel/object"
+	"camel/parser"
+	"camel/token"
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// Options configure an Interpreter. The zero value is ready to use.
+type Options struct {
+	// Filename is the name positions in error messages refer to.
+	Filename string
+	// Dialect selects the keywords scripts are written in; nil
+	// selects English.
+	Dialect *token.Dialect
+	// Globals are defined before the first script runs. The values are
+	// converted with ToObject.
+	Globals map[string]any
+	// Builtins are added to the standard builtins, replacing any of the
+	// same name. The functions are wrapped with NewBuiltin.
+	Builtins map[string]any
+	// Limits bound each call to Eval or Call. The zero value limits
+	// only the call depth, to eval.DefaultMaxDepth.
+	Limits eval.Limits
+}
+
+// Interpreter evaluates camel source in a global environment that
+// persists between calls. It is not safe for concurrent use.
+type Interpreter struct {
+	opts      Options
+	env       *object.Environment
+	evaluator *eval.Evaluator
+}
+
+// Error is a runtime error raised by a script, including a call to
+// exit(). Err holds the error object with its position and call stack.
+type Error struct {
+	Err *object.Error
+}
+
+func (e *Error) Error() string {
+	if e.Err.Pos.IsValid() {
+		return e.Err.Pos.String() + ": " + e.Err.Message
+	}
+	return e.Err.Message
+}
+
+// NewInterpreter returns an Interpreter configured by opts. It panics
+// if one of opts.Globals or opts.Builtins can't be converted, as that
+// is a mistake in the host program.
+func NewInterpreter(opts Options) *Interpreter {
+
+	in := &Interpreter{
+		opts:      opts,
+		env:       object.NewEnvironment(),
+		evaluator: eval.New(),
+	}
+	in.evaluator.SetLimits(opts.Limits)
+	for name, value := range opts.Globals {
+		if err := in.SetGlobal(name, value); err != nil {
+			panic(err)
+		}
+	}
+	for name, fn := range opts.Builtins {
+		if err := in.Register(name, fn); err != nil {
+			panic(err)
+		}
+	}
+	return in
+}
+
+// Register makes the Go function fn, wrapped with NewBuiltin, callable
+// by name from scripts run by this interpreter only. Other
+// interpreters keep their own builtins.
+func (in *Interpreter) Register(name string, fn any) error {
+
+	builtin, err := NewBuiltin(name, fn)
+	if err != nil {
+		return err
+	}
+	in.evaluator.Register(name, builtin)
+	return nil
+}
+
+// Unregister removes the builtin called name from this interpreter,
+// such as exit for scripts that shouldn't end the host's work.
+func (in *Interpreter) Unregister(name string) {
+	in.evaluator.Unregister(name)
+}
+
+// Eval runs src and returns the value of its last statement. Syntax
+// errors are returned as a parser.ErrorList, and nothing is run when
+// there are any; runtime errors are returned as an *Error.
+func (in *Interpreter) Eval(src string) (object.Object, error) {
+	return in.EvalContext(context.Background(), src)
+}
+
+// EvalContext is Eval, stopping the script with an *Error of kind
+// object.CANCELED_ERROR once ctx is done.
+func (in *Interpreter) EvalContext(ctx context.Context, src string) (object.Object, error) {
+
+	lex := lexer.NewFile(in.opts.Filename, src)
+	lex.SetDialect(in.opts.Dialect)
+	p := parser.New(lex)
+	program := p.ParseProgram()
+	if err := p.Errors().Err(); err != nil {
+		return nil, err
+	}
+
+	return result(safely(func() object.Object {
+		return in.evaluator.EvalContext(ctx, program, in.env)
+	}))
+}
+
+// SetGlobal defines name in the global environment, converting value
+// with ToObject. A function is wrapped with NewBuiltin under name.
+func (in *Interpreter) SetGlobal(name string, value any) error {
+
+	var obj object.Object
+	var err error
+	if reflect.ValueOf(value).Kind() == reflect.Func {
+		obj, err = NewBuiltin(name, value)
+	} else {
+		obj, err = ToObject(value)
+	}
+	if err != nil {
+		return fmt.Errorf("camel: global %s: %w", name, err)
+	}
+	in.env.Set(name, obj)
+	return nil
+}
+
+// Global returns the value of the global variable name.
+func (in *Interpreter) Global(name string) (object.Object, bool) {
+	return in.env.Get(name)
+}
+
+// Call calls the function stored in the global variable fnName with
+// args, converted with ToObject, and returns its result.
+func (in *Interpreter) Call(fnName string, args ...any) (object.Object, error) {
+	return in.CallContext(context.Background(), fnName, args...)
+}
+
+// CallContext is Call, stopping the function with an *Error of kind
+// object.CANCELED_ERROR once ctx is done.
+func (in *Interpreter) CallContext(
+	ctx context.Context,
+	fnName string,
+	args ...any,
+) (object.Object, error) {
+
+	fn, ok := in.env.Get(fnName)
+	if !ok {
+		return nil, fmt.Errorf("camel: function %s is not defined", fnName)
+	}
+
+	objs := make([]object.Object, len(args))
+	for i, arg := range args {
+		obj, err := ToObject(arg)
+		if err != nil {
+			return nil, fmt.Errorf("camel: argument %d to %s: %w", i+1, fnName, err)
+		}
+		objs[i] = obj
+	}
+
+	return result(safely(func() object.Object {
+		return in.evaluator.ApplyContext(ctx, fn, objs...)
+	}))
+}
+
+// safely calls f, turning a panic into a runtime error so that a bug
+// a script runs into, in the evaluator or a builtin, doesn't take down
+// the host program.
+func safely(f func() object.Object) (obj object.Object) {
+
+	defer func() {
+		if r := recover(); r != nil {
+			obj = &object.Error{
+				Kind:    object.RUNTIME_ERROR,
+				Message: fmt.Sprintf("internal error: %v", r),
+			}
+		}
+	}()
+	return f()
+}
+
+// result turns the outcome of an evaluation into the values Eval and
+// Call return.
+func result(obj object.Object) (object.Object, error) {
+
+	switch obj := obj.(type) {
+	case nil:
+		return eval.NULL, nil
+	case *object.Error:
+		return nil, &Error{Err: obj}
+	}
+	return obj, nil
+}
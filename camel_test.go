@@ -0,0 +1,481 @@
+package camel
+
+import (
+	"camel/eval"
+	"camel/object"
+	"camel/parser"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEval(t *testing.T) {
+	interp := NewInterpreter(Options{})
+
+	if _, err := interp.Eval("let double = fn(x) { x * 2 }"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	result, err := interp.Eval("double(21)")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if result.Inspect() != "42" {
+		t.Errorf("wrong result. expected=42, got=%s", result.Inspect())
+	}
+
+	result, err = interp.Eval("")
+	if err != nil || result.Type() != object.NULL {
+		t.Errorf("empty program: expected null. got=%v, %v", result, err)
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	interp := NewInterpreter(Options{Filename: "script.cml"})
+
+	_, err := interp.Eval("let x = ; let y = )")
+	var syntaxErrs parser.ErrorList
+	if !errors.As(err, &syntaxErrs) {
+		t.Fatalf("expected parser.ErrorList. got=%T (%v)", err, err)
+	}
+	if len(syntaxErrs) != 2 {
+		t.Errorf("wrong number of syntax errors. expected=2, got=%d", len(syntaxErrs))
+	}
+
+	_, err = interp.Eval("let f = fn() { missing }\nf()")
+	var runtimeErr *Error
+	if !errors.As(err, &runtimeErr) {
+		t.Fatalf("expected *Error. got=%T (%v)", err, err)
+	}
+	if err.Error() != "script.cml:1:16: Identifier not found: missing" {
+		t.Errorf("wrong error message. got=%q", err.Error())
+	}
+	if len(runtimeErr.Err.Stack) != 1 || runtimeErr.Err.Stack[0].Function != "f" {
+		t.Errorf("wrong stack. got=%v", runtimeErr.Err.Stack)
+	}
+
+	_, err = interp.Eval("exit(4)")
+	if !errors.As(err, &runtimeErr) || runtimeErr.Err.Kind != object.EXIT_ERROR ||
+		runtimeErr.Err.Code != 4 {
+		t.Errorf("expected exit error with code 4. got=%v", err)
+	}
+}
+
+func TestSetGlobalAndCall(t *testing.T) {
+	interp := NewInterpreter(Options{
+		Globals: map[string]any{"greeting": "hello"},
+	})
+
+	err := interp.SetGlobal("user", map[string]any{
+		"name":  "Monica",
+		"age":   31,
+		"langs": []string{"go", "camel"},
+		"admin": false,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	src := `let describe = fn(prefix) {
+  prefix + ", " + greeting + " " + user["name"] + " (" + join(user["langs"], "/") + ")"
+}`
+	if _, err := interp.Eval(src); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	result, err := interp.Call("describe", "well")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if result.Inspect() != "well, hello Monica (go/camel)" {
+		t.Errorf("wrong result. got=%q", result.Inspect())
+	}
+
+	if _, err := interp.Call("nope"); err == nil ||
+		err.Error() != "camel: function nope is not defined" {
+		t.Errorf("wrong error for undefined function. got=%v", err)
+	}
+	if _, err := interp.Call("describe"); err == nil ||
+		!strings.Contains(err.Error(), "wrong number of arguments") {
+		t.Errorf("wrong error for bad arity. got=%v", err)
+	}
+	if _, err := interp.Call("user"); err == nil ||
+		!strings.Contains(err.Error(), "HASH is not a function") {
+		t.Errorf("wrong error for calling a hash. got=%v", err)
+	}
+}
+
+func TestGoFunctions(t *testing.T) {
+	interp := NewInterpreter(Options{})
+
+	interp.SetGlobal("sum", func(args ...any) (any, error) {
+		var total int64
+		for _, arg := range args {
+			n, ok := arg.(int64)
+			if !ok {
+				return nil, errors.New("sum takes integers")
+			}
+			total += n
+		}
+		return total, nil
+	})
+
+	result, err := interp.Eval("sum(1, 2, 3) + 1")
+	if err != nil || result.Inspect() != "7" {
+		t.Errorf("expected 7. got=%v, %v", result, err)
+	}
+
+	_, err = interp.Eval(`sum(1, "2")`)
+	if err == nil || err.Error() != "1:1: sum takes integers" {
+		t.Errorf("wrong error. got=%v", err)
+	}
+
+	_, err = interp.Eval("let apply = fn(f, x) { f(x) }; let inc = fn(x) { x + 1 }")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	inc, _ := interp.Global("inc")
+	fn, ok := interp.FromObject(inc).(func(...any) (any, error))
+	if !ok {
+		t.Fatalf("function not converted to a Go func. got=%T", interp.FromObject(inc))
+	}
+	value, err := fn(41)
+	if err != nil || value != int64(42) {
+		t.Errorf("expected 42. got=%v, %v", value, err)
+	}
+}
+
+func TestCallbackUsesCallingEvaluator(t *testing.T) {
+	interp := NewInterpreter(Options{
+		Builtins: map[string]any{
+			"call": func(f any) (any, error) {
+				return f.(func(...any) (any, error))()
+			},
+			"answer": func() int { return 42 },
+		},
+	})
+
+	result, err := interp.Eval("call(fn() { answer() })")
+	if err != nil || result.Inspect() != "42" {
+		t.Errorf("expected 42. got=%v, %v", result, err)
+	}
+
+	_, err = interp.Eval("call(fn() { missing() })")
+	if err == nil || err.Error() != "1:13: Identifier not found: missing" {
+		t.Errorf("wrong error. got=%v", err)
+	}
+}
+
+func TestToObject(t *testing.T) {
+	tests := []struct {
+		input    any
+		expected string
+	}{
+		{nil, "null"},
+		{true, "true"},
+		{int8(-3), "-3"},
+		{uint16(7), "7"},
+		{2.5, "2.5"},
+		{float32(0.5), "0.5"},
+		{"héllo", "héllo"},
+		{[]any{1, "a", nil}, "[1, a, null]"},
+		{[2]int{1, 2}, "[1, 2]"},
+		{map[string]any{"b": 1, "a": []int{2}}, "{a : [2], b : 1}"},
+		{map[int]bool{2: true, 1: false}, "{1 : false, 2 : true}"},
+		{&object.Integer{Value: 5}, "5"},
+	}
+
+	for _, tt := range tests {
+		obj, err := ToObject(tt.input)
+		if err != nil {
+			t.Errorf("%#v: unexpected error: %s", tt.input, err)
+			continue
+		}
+		if obj.Inspect() != tt.expected {
+			t.Errorf("%#v: expected=%q, got=%q", tt.input, tt.expected, obj.Inspect())
+		}
+	}
+
+	for _, input := range []any{uint64(1 << 63), struct{}{}, map[string]chan int{"c": make(chan int)}} {
+		if _, err := ToObject(input); err == nil {
+			t.Errorf("%#v: expected an error", input)
+		}
+	}
+}
+
+func TestToObjectCycle(t *testing.T) {
+	m := map[string]any{}
+	m["self"] = m
+	s := []any{nil}
+	s[0] = s
+	var p any
+	p = &p
+
+	for _, input := range []any{m, s, p, []any{1, map[string]any{"m": m}}} {
+		if _, err := ToObject(input); err == nil ||
+			!strings.HasSuffix(err.Error(), "that contains itself") {
+			t.Errorf("%T: expected a cycle error. got=%v", input, err)
+		}
+	}
+
+	interp := NewInterpreter(Options{})
+	if err := interp.SetGlobal("m", m); err == nil {
+		t.Errorf("expected SetGlobal to fail on a cycle")
+	}
+
+	// A value used twice without containing itself is fine.
+	shared := []any{1}
+	obj, err := ToObject([]any{shared, map[string]any{"a": shared}})
+	if err != nil || obj.Inspect() != "[[1], {a : [1]}]" {
+		t.Errorf("wrong conversion. got=%v, %v", obj, err)
+	}
+}
+
+func TestFromObject(t *testing.T) {
+	interp := NewInterpreter(Options{})
+	result, err := interp.Eval(`{"n": 1, "f": 1.5, "s": "x", "b": true, "z": null, "a": [1, [2]]}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := map[string]any{
+		"n": int64(1),
+		"f": 1.5,
+		"s": "x",
+		"b": true,
+		"z": nil,
+		"a": []any{int64(1), []any{int64(2)}},
+	}
+	if got := interp.FromObject(result); !reflect.DeepEqual(got, expected) {
+		t.Errorf("wrong conversion. expected=%#v, got=%#v", expected, got)
+	}
+
+	result, _ = interp.Eval(`{1: "one", "two": 2}`)
+	expectedAny := map[any]any{int64(1): "one", "two": int64(2)}
+	if got := interp.FromObject(result); !reflect.DeepEqual(got, expectedAny) {
+		t.Errorf("wrong conversion. expected=%#v, got=%#v", expectedAny, got)
+	}
+}
+
+func TestFromObjectCycle(t *testing.T) {
+	interp := NewInterpreter(Options{})
+	result, err := interp.Eval(`let a = [1]; a[0] = a; let h = {"a": a}; h["h"] = h; h`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	h, ok := interp.FromObject(result).(map[string]any)
+	if !ok {
+		t.Fatalf("hash not converted to a map. got=%T", interp.FromObject(result))
+	}
+	if reflect.ValueOf(h["h"]).Pointer() != reflect.ValueOf(h).Pointer() {
+		t.Errorf("h[\"h\"] is not the map itself. got=%T", h["h"])
+	}
+	a, ok := h["a"].([]any)
+	if !ok || len(a) != 1 {
+		t.Fatalf("array not converted to a slice. got=%T", h["a"])
+	}
+	if inner, ok := a[0].([]any); !ok || &inner[0] != &a[0] {
+		t.Errorf("a[0] is not the slice itself. got=%T", a[0])
+	}
+}
+
+func TestRegister(t *testing.T) {
+	interp := NewInterpreter(Options{
+		Builtins: map[string]any{
+			"shout": strings.ToUpper,
+		},
+	})
+	other := NewInterpreter(Options{})
+
+	err := interp.Register("div", func(a, b int) (int, error) {
+		if b == 0 {
+			return 0, errors.New("div: divide by zero")
+		}
+		return a / b, nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	result, err := interp.Eval(`shout("hi ${div(7, 2)}")`)
+	if err != nil || result.Inspect() != "HI 3" {
+		t.Errorf("expected HI 3. got=%v, %v", result, err)
+	}
+
+	if _, err := other.Eval("div(1, 1)"); err == nil ||
+		err.Error() != "1:1: Identifier not found: div" {
+		t.Errorf("builtin leaked into another interpreter. got=%v", err)
+	}
+
+	if _, err := interp.Eval("div(1, 0)"); err == nil ||
+		err.Error() != "1:1: div: divide by zero" {
+		t.Errorf("wrong error. got=%v", err)
+	}
+
+	interp.Register("len", func(v any) int { return 99 })
+	if result, _ := interp.Eval("len([])"); result.Inspect() != "99" {
+		t.Errorf("builtin not replaced. got=%s", result.Inspect())
+	}
+	interp.Unregister("exit")
+	if _, err := interp.Eval("exit(1)"); err == nil ||
+		err.Error() != "1:1: Identifier not found: exit" {
+		t.Errorf("builtin not removed. got=%v", err)
+	}
+	if _, err := other.Eval("len([])"); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	if err := interp.Register("bad", 5); err == nil {
+		t.Errorf("expected error registering a non-function")
+	}
+	if err := interp.Register("bad", func() (int, int) { return 1, 2 }); err == nil {
+		t.Errorf("expected error registering a function with two results")
+	}
+}
+
+func TestNewBuiltin(t *testing.T) {
+	funcs := map[string]any{
+		"small":   func(n int8) int8 { return n },
+		"natural": func(n uint) uint { return n },
+		"half":    func(f float64) float64 { return f / 2 },
+		"total": func(ns []int64) (sum int64) {
+			for _, n := range ns {
+				sum += n
+			}
+			return
+		},
+		"count":  func(m map[string][]string) int { return len(m) },
+		"first":  func(s string, rest ...string) int { return len(rest) },
+		"typeOf": func(obj object.Object) string { return string(obj.Type()) },
+		"call":   func(fn *object.Function) string { return fn.Name },
+		"maybe":  func(p *int, s []int) bool { return p == nil && s == nil },
+		"noop":   func() {},
+		"fail":   func() error { return errors.New("failed") },
+		"ok":     func() error { return nil },
+		"chan":   func(c chan int) {},
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"small(-128)", "-128"},
+		{"small(128)", "Error: argument 1 to small: 128 overflows int8"},
+		{"natural(-1)", "Error: argument 1 to natural: -1 overflows uint"},
+		{"half(3)", "1.5"},
+		{`half("3")`, "Error: argument 1 to half must be number, got STRING"},
+		{"total([1, 2, 3])", "6"},
+		{`total([1, "2"])`, "Error: argument 1 to total[1] must be integer, got STRING"},
+		{`count({"a": ["x"], "b": []})`, "2"},
+		{`count({"a": [1]})`, "Error: argument 1 to count[a][0] must be string, got INTEGER"},
+		{`count({1: []})`, "Error: argument 1 to count key must be string, got INTEGER"},
+		{`first("a")`, "0"},
+		{`first("a", "b", "c")`, "2"},
+		{`first("a", 1)`, "Error: argument 2 to first must be string, got INTEGER"},
+		{"first()", "Error: wrong number of arguments, expected:at least 1, got: 0"},
+		{"small(1, 2)", "Error: wrong number of arguments, expected:1, got: 2"},
+		{"typeOf(null)", "NULL"},
+		{"typeOf([])", "ARRAY"},
+		{"let f = fn() {}; call(f)", "f"},
+		{"call(len)", "Error: argument 1 to call must be function, got BUILTIN"},
+		{"maybe(null, null)", "true"},
+		{"noop()", "null"},
+		{"fail()", "Error: failed"},
+		{"ok()", "null"},
+		{"chan(null)", "null"},
+		{"chan(1)", "Error: argument 1 to chan must be chan int, got INTEGER"},
+	}
+
+	interp := NewInterpreter(Options{Builtins: funcs})
+	for _, tt := range tests {
+		result, err := interp.Eval(tt.input)
+		var got string
+		if err != nil {
+			var runtimeErr *Error
+			errors.As(err, &runtimeErr)
+			got = "Error: " + runtimeErr.Err.Message
+		} else {
+			got = result.Inspect()
+		}
+		if got != tt.expected {
+			t.Errorf("%q: expected=%q, got=%q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestPanicRecovered(t *testing.T) {
+	interp := NewInterpreter(Options{
+		Builtins: map[string]any{
+			"boom": func() { panic("boom") },
+		},
+	})
+
+	var runtimeErr *Error
+	_, err := interp.Eval("let f = fn() { boom() }; f()")
+	if !errors.As(err, &runtimeErr) || runtimeErr.Err.Kind != object.RUNTIME_ERROR ||
+		runtimeErr.Err.Message != "internal error: boom" {
+		t.Errorf("expected an internal error. got=%v", err)
+	}
+	_, err = interp.Call("f")
+	if !errors.As(err, &runtimeErr) || runtimeErr.Err.Message != "internal error: boom" {
+		t.Errorf("expected an internal error. got=%v", err)
+	}
+
+	// The interpreter is still usable afterwards.
+	result, err := interp.Eval("1 + 1")
+	if err != nil || result.Inspect() != "2" {
+		t.Errorf("expected 2. got=%v, %v", result, err)
+	}
+}
+
+func TestLimitsAndContext(t *testing.T) {
+	interp := NewInterpreter(Options{
+		Limits: eval.Limits{MaxSteps: 10000, MaxElements: 1000},
+	})
+
+	var runtimeErr *Error
+	_, err := interp.Eval("while (true) {}")
+	if !errors.As(err, &runtimeErr) || runtimeErr.Err.Kind != object.LIMIT_ERROR {
+		t.Errorf("expected limit error. got=%v", err)
+	}
+	_, err = interp.Eval("range(5000)")
+	if !errors.As(err, &runtimeErr) || runtimeErr.Err.Kind != object.LIMIT_ERROR {
+		t.Errorf("expected limit error. got=%v", err)
+	}
+
+	// The interpreter is still usable after a limit stopped a script.
+	if _, err := interp.Eval("let spin = fn() { while (true) {} }; 1"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// Converted functions run under the interpreter's limits, whether
+	// a builtin or the host calls them.
+	interp.Register("call", func(f any) (any, error) {
+		return f.(func(...any) (any, error))()
+	})
+	_, err = interp.Eval("call(spin)")
+	if !errors.As(err, &runtimeErr) || runtimeErr.Err.Kind != object.LIMIT_ERROR {
+		t.Errorf("expected limit error. got=%v", err)
+	}
+	spin, _ := interp.Global("spin")
+	_, err = interp.FromObject(spin).(func(...any) (any, error))()
+	if !errors.As(err, &runtimeErr) || runtimeErr.Err.Kind != object.LIMIT_ERROR {
+		t.Errorf("expected limit error. got=%v", err)
+	}
+
+	unlimited := NewInterpreter(Options{})
+	unlimited.Eval("let spin = fn() { while (true) {} }")
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = unlimited.CallContext(ctx, "spin")
+	if !errors.As(err, &runtimeErr) || runtimeErr.Err.Kind != object.CANCELED_ERROR {
+		t.Errorf("expected canceled error. got=%v", err)
+	}
+	_, err = unlimited.EvalContext(ctx, "1 + 1")
+	if !errors.As(err, &runtimeErr) || runtimeErr.Err.Kind != object.CANCELED_ERROR {
+		t.Errorf("expected canceled error. got=%v", err)
+	}
+}
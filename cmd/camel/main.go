@@ -0,0 +1,139 @@
+package main
+
+import (
+	"camel"
+	"camel/eval"
+	"camel/object"
+	"camel/parser"
+	"camel/repl"
+	"camel/token"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/user"
+)
+
+const usage = `Usage:
+  camel                        start the interactive interpreter
+  camel run script.cml [args]  run a script
+  camel script.cml [args]      same as run
+  camel -e 'expr' [args]       evaluate an expression and print the result
+
+Options:
+`
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flag.PrintDefaults()
+	}
+	expr := flag.String("e", "", "evaluate `expr` and print its value")
+	dialectName := flag.String("dialect", token.English.Name,
+		"keywords to use: english, persian, or a JSON `dialect` file")
+	flag.Parse()
+
+	dialect, err := loadDialect(*dialectName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "camel: %s\n", err)
+		os.Exit(2)
+	}
+
+	args := flag.Args()
+
+	if *expr != "" {
+		os.Exit(run("-e", *expr, args, dialect, true))
+	}
+
+	if len(args) == 0 {
+		os.Exit(startRepl(dialect))
+	}
+
+	if args[0] == "run" {
+		args = args[1:]
+		if len(args) == 0 {
+			flag.Usage()
+			os.Exit(2)
+		}
+	}
+
+	os.Exit(runFile(args[0], args[1:], dialect))
+}
+
+// loadDialect returns the built-in dialect called name, or else reads
+// the dialect from the JSON file name.
+func loadDialect(name string) (*token.Dialect, error) {
+
+	if dialect, ok := token.Dialects[name]; ok {
+		return dialect, nil
+	}
+
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown dialect %q", name)
+	}
+	return token.ParseDialect(data)
+}
+
+func startRepl(dialect *token.Dialect) int {
+	user, err := user.Current()
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println(repl.Logo)
+	fmt.Printf("Hello! %s, This is camel programming language!\n",
+		user.Username)
+	fmt.Printf("Feel free to type in commands\n")
+
+	return repl.Start(os.Stdin, os.Stdout, dialect)
+}
+
+func runFile(filename string, args []string, dialect *token.Dialect) int {
+
+	src, err := os.ReadFile(filename)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "camel: %s\n", err)
+		return 1
+	}
+	return run(filename, string(src), args, dialect, false)
+}
+
+// run evaluates src and returns the process exit status. Script
+// arguments are available to the program as the `args` array.
+func run(
+	filename, src string,
+	args []string,
+	dialect *token.Dialect,
+	printResult bool,
+) int {
+
+	interp := camel.NewInterpreter(camel.Options{
+		Filename: filename,
+		Dialect:  dialect,
+		Globals:  map[string]any{"args": args},
+	})
+
+	result, err := interp.Eval(src)
+
+	var syntaxErrs parser.ErrorList
+	var runtimeErr *camel.Error
+	switch {
+	case errors.As(err, &syntaxErrs):
+		for _, err := range syntaxErrs {
+			fmt.Fprintf(os.Stderr, "Syntax error: %s\n", err)
+		}
+		return 1
+	case errors.As(err, &runtimeErr):
+		if runtimeErr.Err.Kind == object.EXIT_ERROR {
+			return runtimeErr.Err.Code
+		}
+		fmt.Fprintln(os.Stderr, runtimeErr.Err.Inspect())
+		return 1
+	}
+
+	if printResult && result != eval.NULL {
+		fmt.Println(result.Inspect())
+	}
+	return 0
+}
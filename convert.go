@@ -0,0 +1,227 @@
+package camel
+
+import (
+	"camel/eval"
+	"camel/object"
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// ToObject converts a Go value to a camel value:
+//
+//   - nil becomes null
+//   - bool, string and the integer and float types become booleans,
+//     strings, integers and floats; unsigned integers that don't fit
+//     in an int64 are an error
+//   - slices and arrays, such as []any, become arrays
+//   - maps with string, integer or bool keys, such as map[string]any,
+//     become hashes
+//   - functions become builtins, as described by NewBuiltin
+//
+// An object.Object is returned as it is. A value that contains itself,
+// such as a map stored in one of its own elements, is an error.
+func ToObject(v any) (object.Object, error) {
+	return toObject(v, map[visit]bool{})
+}
+
+// visit identifies a map, slice or pointer being converted.
+type visit struct {
+	ptr uintptr
+	typ reflect.Type
+	len int
+}
+
+// toObject converts v like ToObject. seen holds the maps, slices and
+// pointers being converted around v, so meeting one of them again
+// reports a cycle instead of recursing forever.
+func toObject(v any, seen map[visit]bool) (object.Object, error) {
+
+	switch v := v.(type) {
+	case nil:
+		return eval.NULL, nil
+	case object.Object:
+		return v, nil
+	case bool:
+		if v {
+			return eval.TRUE, nil
+		}
+		return eval.FALSE, nil
+	case string:
+		return &object.String{Value: v}, nil
+	case int64:
+		return &object.Integer{Value: v}, nil
+	case int:
+		return &object.Integer{Value: int64(v)}, nil
+	case float64:
+		return &object.Float{Value: v}, nil
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Pointer:
+		if ptr := rv.Pointer(); ptr != 0 {
+			key := visit{ptr: ptr, typ: rv.Type()}
+			if rv.Kind() == reflect.Slice {
+				key.len = rv.Len()
+			}
+			if seen[key] {
+				return nil, fmt.Errorf("cannot convert %T that contains itself", v)
+			}
+			seen[key] = true
+			defer delete(seen, key)
+		}
+	}
+
+	switch rv.Kind() {
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &object.Integer{Value: rv.Int()}, nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64, reflect.Uintptr:
+		if rv.Uint() > math.MaxInt64 {
+			return nil, fmt.Errorf("%d overflows a camel integer", rv.Uint())
+		}
+		return &object.Integer{Value: int64(rv.Uint())}, nil
+
+	case reflect.Float32, reflect.Float64:
+		return &object.Float{Value: rv.Float()}, nil
+
+	case reflect.Bool:
+		return ToObject(rv.Bool())
+
+	case reflect.String:
+		return &object.String{Value: rv.String()}, nil
+
+	case reflect.Slice, reflect.Array:
+		elements := make([]object.Object, rv.Len())
+		for i := range elements {
+			elem, err := toObject(rv.Index(i).Interface(), seen)
+			if err != nil {
+				return nil, err
+			}
+			elements[i] = elem
+		}
+		return &object.Array{Elements: elements}, nil
+
+	case reflect.Map:
+		pairs := make(map[object.HashKey]object.HashPair, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			key, err := toObject(iter.Key().Interface(), seen)
+			if err != nil {
+				return nil, err
+			}
+			hashable, ok := key.(object.Hashable)
+			if !ok {
+				return nil, fmt.Errorf("unhashable map key type %s",
+					iter.Key().Type())
+			}
+			value, err := toObject(iter.Value().Interface(), seen)
+			if err != nil {
+				return nil, err
+			}
+			pairs[hashable.HashKey()] = object.HashPair{Key: key, Value: value}
+		}
+		return &object.Hash{Pairs: pairs}, nil
+
+	case reflect.Func:
+		return NewBuiltin("function", v)
+
+	case reflect.Pointer, reflect.Interface:
+		if rv.IsNil() {
+			return eval.NULL, nil
+		}
+		return toObject(rv.Elem().Interface(), seen)
+	}
+
+	return nil, fmt.Errorf("cannot convert %T to a camel value", v)
+}
+
+// FromObject converts a camel value to a Go value: null becomes nil,
+// integers int64, floats float64, strings string, booleans bool,
+// arrays []any and hashes map[string]any, or map[any]any when a key
+// isn't a string. Functions and builtins become a
+// func(...any) (any, error) that calls them with this interpreter's
+// builtins and limits. Other objects are returned as they are. An
+// array or hash that holds itself becomes a slice or map that holds
+// itself.
+func (in *Interpreter) FromObject(obj object.Object) any {
+	return fromObject(in.evaluator, obj, map[object.Object]any{})
+}
+
+// fromObject converts obj like FromObject, with functions called by e.
+// seen maps the arrays and hashes converted so far to their Go values,
+// so each is converted once and cycles are kept rather than followed
+// forever.
+func fromObject(e object.Evaluator, obj object.Object, seen map[object.Object]any) any {
+
+	if value, ok := seen[obj]; ok {
+		return value
+	}
+
+	switch obj := obj.(type) {
+	case nil, *object.Null:
+		return nil
+	case *object.Integer:
+		return obj.Value
+	case *object.Float:
+		return obj.Value
+	case *object.String:
+		return obj.Value
+	case *object.Boolean:
+		return obj.Value
+
+	case *object.Array:
+		elements := make([]any, len(obj.Elements))
+		seen[obj] = elements
+		for i, elem := range obj.Elements {
+			elements[i] = fromObject(e, elem, seen)
+		}
+		return elements
+
+	case *object.Hash:
+		for _, pair := range obj.Pairs {
+			if _, ok := pair.Key.(*object.String); !ok {
+				return anyKeys(e, obj, seen)
+			}
+		}
+		values := make(map[string]any, len(obj.Pairs))
+		seen[obj] = values
+		for _, pair := range obj.Pairs {
+			values[pair.Key.(*object.String).Value] = fromObject(e, pair.Value, seen)
+		}
+		return values
+
+	case *object.Function, *object.Builtin:
+		return func(args ...any) (any, error) {
+			objs := make([]object.Object, len(args))
+			for i, arg := range args {
+				var err error
+				if objs[i], err = ToObject(arg); err != nil {
+					return nil, err
+				}
+			}
+			value, err := result(safely(func() object.Object {
+				return e.Apply(obj, objs...)
+			}))
+			if err != nil {
+				return nil, err
+			}
+			return fromObject(e, value, map[object.Object]any{}), nil
+		}
+	}
+
+	return obj
+}
+
+func anyKeys(e object.Evaluator, hash *object.Hash, seen map[object.Object]any) map[any]any {
+
+	m := make(map[any]any, len(hash.Pairs))
+	seen[hash] = m
+	for _, pair := range hash.Pairs {
+		m[fromObject(e, pair.Key, seen)] = fromObject(e, pair.Value, seen)
+	}
+	return m
+}
@@ -1,21 +1,26 @@
 package eval
 
 import (
-	"fmt"
 	"camel/object"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
 )
 
 var builtins = map[string]*object.Builtin{
 	"chap": &object.Builtin{
-		Fn: func(args ...object.Object) object.Object {
-			for _, arg := range args { 
-				fmt.Println(arg.Inspect()) 
+		Fn: func(e object.Evaluator, args ...object.Object) object.Object {
+			for _, arg := range args {
+				fmt.Println(arg.Inspect())
 			}
 			return NULL
 		},
-	},	
+	},
 	"len": &object.Builtin{
-		Fn: func(args ...object.Object) object.Object {
+		Fn: func(e object.Evaluator, args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return newError(
 					"wrong number of arguments, "+
@@ -26,7 +31,7 @@ var builtins = map[string]*object.Builtin{
 			switch arg := args[0].(type) {
 
 			case *object.String:
-				return &object.Integer{Value: int64(len(arg.Value))}
+				return &object.Integer{Value: int64(utf8.RuneCountInString(arg.Value))}
 			case *object.Array:
 				return &object.Integer{Value: int64(len(arg.Elements))}
 			default:
@@ -37,8 +42,33 @@ var builtins = map[string]*object.Builtin{
 			}
 		},
 	},
+	"bytes": &object.Builtin{
+		Fn: func(e object.Evaluator, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(
+					"wrong number of arguments, "+
+						"expected:1, got: %d", len(args),
+				)
+			}
+
+			arg, ok := args[0].(*object.String)
+			if !ok {
+				return newError("argument to bytes must be string,"+
+					" got %s", args[0].Type())
+			}
+
+			if err := allocate(e, len(arg.Value)); err != nil {
+				return err
+			}
+			elements := make([]object.Object, len(arg.Value))
+			for i := 0; i < len(arg.Value); i++ {
+				elements[i] = &object.Integer{Value: int64(arg.Value[i])}
+			}
+			return &object.Array{Elements: elements}
+		},
+	},
 	"peek": &object.Builtin{
-		Fn: func(args ...object.Object) object.Object {
+		Fn: func(e object.Evaluator, args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return newError(
 					"wrong number of arguments, "+
@@ -54,12 +84,15 @@ var builtins = map[string]*object.Builtin{
 			}
 
 			arr := arg.Elements
+			if len(arr) == 0 {
+				return newError("peek at empty array")
+			}
 			return arr[len(arr)-1]
 		},
 	},
 
 	"pop": &object.Builtin{
-		Fn: func(args ...object.Object) object.Object {
+		Fn: func(e object.Evaluator, args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return newError(
 					"wrong number of arguments, "+
@@ -70,16 +103,111 @@ var builtins = map[string]*object.Builtin{
 			arg, ok := args[0].(*object.Array)
 
 			if !ok {
-				return newError("argument to peek must be array,"+
+				return newError("argument to pop must be array,"+
 					" got %s", args[0].Type())
 			}
 
 			arr := arg.Elements
-			return &object.Array{Elements: arr[:len(arr)-1]}
+			if len(arr) == 0 {
+				return newError("pop from empty array")
+			}
+			last := arr[len(arr)-1]
+			arg.Elements = arr[:len(arr)-1]
+			return last
+		},
+	},
+	"exit": &object.Builtin{
+		Fn: func(e object.Evaluator, args ...object.Object) object.Object {
+			if len(args) > 1 {
+				return newError(
+					"wrong number of arguments, "+
+						"expected:0 or 1, got: %d", len(args),
+				)
+			}
+
+			code := int64(0)
+			if len(args) == 1 {
+				arg, ok := args[0].(*object.Integer)
+				if !ok {
+					return newError("argument to exit must be integer,"+
+						" got %s", args[0].Type())
+				}
+				code = arg.Value
+			}
+
+			return &object.Error{
+				Kind:    object.EXIT_ERROR,
+				Message: fmt.Sprintf("exit status %d", code),
+				Code:    int(code),
+			}
+		},
+	},
+	"int": &object.Builtin{
+		Fn: func(e object.Evaluator, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(
+					"wrong number of arguments, "+
+						"expected:1, got: %d", len(args),
+				)
+			}
+
+			switch arg := args[0].(type) {
+
+			case *object.Integer:
+				return arg
+			case *object.Float:
+				// Truncates toward zero. The bounds are exact powers of
+				// two, so the comparisons are exact as well.
+				if math.IsNaN(arg.Value) ||
+					arg.Value >= math.MaxInt64 || arg.Value < math.MinInt64 {
+					return newError("cannot convert %s to integer", arg.Inspect())
+				}
+				return &object.Integer{Value: int64(arg.Value)}
+			case *object.String:
+				value, err := strconv.ParseInt(strings.TrimSpace(arg.Value), 10, 64)
+				if err != nil {
+					return newError("cannot convert %q to integer", arg.Value)
+				}
+				return &object.Integer{Value: value}
+			default:
+				return newError(
+					"argument to `int` not supported, got: "+
+						"%s", arg.Type(),
+				)
+			}
+		},
+	},
+	"float": &object.Builtin{
+		Fn: func(e object.Evaluator, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(
+					"wrong number of arguments, "+
+						"expected:1, got: %d", len(args),
+				)
+			}
+
+			switch arg := args[0].(type) {
+
+			case *object.Integer:
+				return &object.Float{Value: float64(arg.Value)}
+			case *object.Float:
+				return arg
+			case *object.String:
+				value, err := strconv.ParseFloat(strings.TrimSpace(arg.Value), 64)
+				if err != nil {
+					return newError("cannot convert %q to float", arg.Value)
+				}
+				return &object.Float{Value: value}
+			default:
+				return newError(
+					"argument to `float` not supported, got: "+
+						"%s", arg.Type(),
+				)
+			}
 		},
 	},
 	"push": &object.Builtin{
-		Fn: func(args ...object.Object) object.Object {
+		Fn: func(e object.Evaluator, args ...object.Object) object.Object {
 			if len(args) != 2 {
 				return newError(
 					"wrong number of arguments, "+
@@ -90,10 +218,13 @@ var builtins = map[string]*object.Builtin{
 			arg, ok := args[0].(*object.Array)
 
 			if !ok {
-				return newError("argument to peek must be array,"+
+				return newError("argument to push must be array,"+
 					" got %s", args[0].Type())
 			}
 
+			if err := allocate(e, len(arg.Elements)+1); err != nil {
+				return err
+			}
 			elem := args[1]
 			Elements := arg.Elements
 			length := len(Elements)
@@ -107,4 +238,737 @@ var builtins = map[string]*object.Builtin{
 			return &object.Array{Elements: newElements}
 		},
 	},
+	"push!": &object.Builtin{
+		Fn: func(e object.Evaluator, args ...object.Object) object.Object {
+			return appendElements(e, "push!", args)
+		},
+	},
+	"append": &object.Builtin{
+		Fn: func(e object.Evaluator, args ...object.Object) object.Object {
+			return appendElements(e, "append", args)
+		},
+	},
+	"insert": &object.Builtin{
+		Fn: func(e object.Evaluator, args ...object.Object) object.Object {
+			if len(args) != 3 {
+				return newError(
+					"wrong number of arguments, "+
+						"expected:3, got: %d", len(args),
+				)
+			}
+
+			arg, ok := args[0].(*object.Array)
+			if !ok {
+				return newError("argument to insert must be array,"+
+					" got %s", args[0].Type())
+			}
+			index, ok := args[1].(*object.Integer)
+			if !ok {
+				return newError("index to insert must be integer,"+
+					" got %s", args[1].Type())
+			}
+
+			// Inserting at the length appends, and -1 inserts before
+			// the last element.
+			arr := arg.Elements
+			i := index.Value
+			if i < 0 {
+				i += int64(len(arr))
+			}
+			if i < 0 || i > int64(len(arr)) {
+				return indexOutOfRange(index.Value, int64(len(arr)))
+			}
+			if err := allocate(e, 1); err != nil {
+				return err
+			}
+
+			arr = append(arr, nil)
+			copy(arr[i+1:], arr[i:])
+			arr[i] = args[2]
+			arg.Elements = arr
+			return arg
+		},
+	},
+	"remove": &object.Builtin{
+		Fn: func(e object.Evaluator, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError(
+					"wrong number of arguments, "+
+						"expected:2, got: %d", len(args),
+				)
+			}
+
+			arg, ok := args[0].(*object.Array)
+			if !ok {
+				return newError("argument to remove must be array,"+
+					" got %s", args[0].Type())
+			}
+			index, ok := args[1].(*object.Integer)
+			if !ok {
+				return newError("index to remove must be integer,"+
+					" got %s", args[1].Type())
+			}
+
+			arr := arg.Elements
+			i, ok := resolveIndex(index.Value, int64(len(arr)))
+			if !ok {
+				return indexOutOfRange(index.Value, int64(len(arr)))
+			}
+
+			removed := arr[i]
+			arg.Elements = append(arr[:i], arr[i+1:]...)
+			return removed
+		},
+	},
+	"clear": &object.Builtin{
+		Fn: func(e object.Evaluator, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(
+					"wrong number of arguments, "+
+						"expected:1, got: %d", len(args),
+				)
+			}
+
+			switch arg := args[0].(type) {
+
+			case *object.Array:
+				arg.Elements = []object.Object{}
+				return arg
+			case *object.Hash:
+				arg.Pairs = map[object.HashKey]object.HashPair{}
+				return arg
+			default:
+				return newError(
+					"argument to `clear` not supported, got: "+
+						"%s", arg.Type(),
+				)
+			}
+		},
+	},
+	"delete": &object.Builtin{
+		Fn: func(e object.Evaluator, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError(
+					"wrong number of arguments, "+
+						"expected:2, got: %d", len(args),
+				)
+			}
+
+			arg, ok := args[0].(*object.Hash)
+			if !ok {
+				return newError("argument to delete must be hash,"+
+					" got %s", args[0].Type())
+			}
+			key, ok := args[1].(object.Hashable)
+			if !ok {
+				return newError("Unhashable type %s "+
+					"used as index", args[1].Type())
+			}
+
+			// Deleting a missing key is not an error; it gives null.
+			pair, ok := arg.Pairs[key.HashKey()]
+			if !ok {
+				return NULL
+			}
+			delete(arg.Pairs, key.HashKey())
+			return pair.Value
+		},
+	},
+	"set": &object.Builtin{
+		Fn: func(e object.Evaluator, args ...object.Object) object.Object {
+			if len(args) != 3 {
+				return newError(
+					"wrong number of arguments, "+
+						"expected:3, got: %d", len(args),
+				)
+			}
+
+			arg, ok := args[0].(*object.Hash)
+			if !ok {
+				return newError("argument to set must be hash,"+
+					" got %s", args[0].Type())
+			}
+			key, ok := args[1].(object.Hashable)
+			if !ok {
+				return newError("Unhashable type %s "+
+					"used as index", args[1].Type())
+			}
+
+			if _, ok := arg.Pairs[key.HashKey()]; !ok {
+				if err := allocate(e, 1); err != nil {
+					return err
+				}
+			}
+			arg.Pairs[key.HashKey()] = object.HashPair{Key: args[1], Value: args[2]}
+			return arg
+		},
+	},
+	"map": &object.Builtin{
+		Fn: func(e object.Evaluator, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError(
+					"wrong number of arguments, "+
+						"expected:2, got: %d", len(args),
+				)
+			}
+
+			elements, err := iterate(args[0])
+			if err != nil {
+				return err
+			}
+
+			if err := allocate(e, len(elements)); err != nil {
+				return err
+			}
+			result := make([]object.Object, len(elements))
+			for i, elem := range elements {
+				if err := e.Step(); err != nil {
+					return err
+				}
+				value := e.Apply(args[1], elem)
+				if isError(value) {
+					return value
+				}
+				result[i] = value
+			}
+			return &object.Array{Elements: result}
+		},
+	},
+	"filter": &object.Builtin{
+		Fn: func(e object.Evaluator, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError(
+					"wrong number of arguments, "+
+						"expected:2, got: %d", len(args),
+				)
+			}
+
+			elements, err := iterate(args[0])
+			if err != nil {
+				return err
+			}
+
+			result := []object.Object{}
+			for _, elem := range elements {
+				if err := e.Step(); err != nil {
+					return err
+				}
+				keep := e.Apply(args[1], elem)
+				if isError(keep) {
+					return keep
+				}
+				if isTrue(keep) {
+					result = append(result, elem)
+				}
+			}
+			if err := allocate(e, len(result)); err != nil {
+				return err
+			}
+			return &object.Array{Elements: result}
+		},
+	},
+	"reduce": &object.Builtin{
+		Fn: func(e object.Evaluator, args ...object.Object) object.Object {
+			if len(args) != 2 && len(args) != 3 {
+				return newError(
+					"wrong number of arguments, "+
+						"expected:2 or 3, got: %d", len(args),
+				)
+			}
+
+			elements, err := iterate(args[0])
+			if err != nil {
+				return err
+			}
+
+			// Without an initial value the first element is used.
+			var acc object.Object
+			if len(args) == 3 {
+				acc = args[2]
+			} else if len(elements) == 0 {
+				return newError("reduce of empty %s with no initial value",
+					args[0].Type())
+			} else {
+				acc, elements = elements[0], elements[1:]
+			}
+
+			for _, elem := range elements {
+				if err := e.Step(); err != nil {
+					return err
+				}
+				acc = e.Apply(args[1], acc, elem)
+				if isError(acc) {
+					return acc
+				}
+			}
+			return acc
+		},
+	},
+	"each": &object.Builtin{
+		Fn: func(e object.Evaluator, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError(
+					"wrong number of arguments, "+
+						"expected:2, got: %d", len(args),
+				)
+			}
+
+			elements, err := iterate(args[0])
+			if err != nil {
+				return err
+			}
+
+			for _, elem := range elements {
+				if err := e.Step(); err != nil {
+					return err
+				}
+				if result := e.Apply(args[1], elem); isError(result) {
+					return result
+				}
+			}
+			return NULL
+		},
+	},
+	"sort": &object.Builtin{
+		Fn: func(e object.Evaluator, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError(
+					"wrong number of arguments, "+
+						"expected:1 or 2, got: %d", len(args),
+				)
+			}
+
+			arg, ok := args[0].(*object.Array)
+			if !ok {
+				return newError("argument to sort must be array,"+
+					" got %s", args[0].Type())
+			}
+
+			// Elements are ordered with < unless a function telling
+			// whether its first argument goes before its second is given.
+			less := func(a, b object.Object) object.Object {
+				return evalInfixExpression("<", a, b)
+			}
+			if len(args) == 2 {
+				less = func(a, b object.Object) object.Object {
+					return e.Apply(args[1], a, b)
+				}
+			}
+
+			if err := allocate(e, len(arg.Elements)); err != nil {
+				return err
+			}
+			sorted := make([]object.Object, len(arg.Elements))
+			copy(sorted, arg.Elements)
+
+			var failed object.Object
+			sort.SliceStable(sorted, func(i, j int) bool {
+				if failed != nil {
+					return false
+				}
+				result := less(sorted[i], sorted[j])
+				if isError(result) {
+					failed = result
+					return false
+				}
+				return isTrue(result)
+			})
+			if failed != nil {
+				return failed
+			}
+			return &object.Array{Elements: sorted}
+		},
+	},
+	"range": &object.Builtin{
+		Fn: func(e object.Evaluator, args ...object.Object) object.Object {
+			if len(args) < 1 || len(args) > 3 {
+				return newError(
+					"wrong number of arguments, "+
+						"expected:1 to 3, got: %d", len(args),
+				)
+			}
+
+			bounds := make([]int64, len(args))
+			for i, arg := range args {
+				n, ok := arg.(*object.Integer)
+				if !ok {
+					return newError("argument to range must be integer,"+
+						" got %s", arg.Type())
+				}
+				bounds[i] = n.Value
+			}
+
+			// range(end), range(start, end) and range(start, end, step)
+			// count from start up to, but not including, end.
+			start, end, step := int64(0), bounds[0], int64(1)
+			if len(bounds) > 1 {
+				start, end = bounds[0], bounds[1]
+			}
+			if len(bounds) > 2 {
+				step = bounds[2]
+			}
+			if step == 0 {
+				return newError("range step must not be zero")
+			}
+
+			n, ok := rangeLength(start, end, step)
+			if !ok {
+				return newError("range from %d to %d by %d is too long",
+					start, end, step)
+			}
+			if err := allocate(e, n); err != nil {
+				return err
+			}
+
+			// The array grows as it is filled, so a range that is
+			// stopped part way hasn't taken all of its memory first.
+			elements := []object.Object{}
+			for i := 0; i < n; i++ {
+				if err := e.Step(); err != nil {
+					return err
+				}
+				// Wrapping arithmetic lands on the right value, as it
+				// lies between start and end.
+				elements = append(elements, &object.Integer{Value: start + int64(i)*step})
+			}
+			return &object.Array{Elements: elements}
+		},
+	},
+	"zip": &object.Builtin{
+		Fn: func(e object.Evaluator, args ...object.Object) object.Object {
+			if len(args) < 1 {
+				return newError(
+					"wrong number of arguments, "+
+						"expected:at least 1, got: %d", len(args),
+				)
+			}
+
+			// The result is as long as the shortest array.
+			arrays := make([]*object.Array, len(args))
+			length := -1
+			for i, arg := range args {
+				arr, ok := arg.(*object.Array)
+				if !ok {
+					return newError("argument to zip must be array,"+
+						" got %s", arg.Type())
+				}
+				arrays[i] = arr
+				if length < 0 || len(arr.Elements) < length {
+					length = len(arr.Elements)
+				}
+			}
+
+			if err := allocate(e, length*(len(arrays)+1)); err != nil {
+				return err
+			}
+			tuples := make([]object.Object, length)
+			for i := range tuples {
+				tuple := make([]object.Object, len(arrays))
+				for j, arr := range arrays {
+					tuple[j] = arr.Elements[i]
+				}
+				tuples[i] = &object.Array{Elements: tuple}
+			}
+			return &object.Array{Elements: tuples}
+		},
+	},
+	"enumerate": &object.Builtin{
+		Fn: func(e object.Evaluator, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(
+					"wrong number of arguments, "+
+						"expected:1, got: %d", len(args),
+				)
+			}
+
+			elements, err := iterate(args[0])
+			if err != nil {
+				return err
+			}
+
+			if err := allocate(e, 3*len(elements)); err != nil {
+				return err
+			}
+			pairs := make([]object.Object, len(elements))
+			for i, elem := range elements {
+				pairs[i] = &object.Array{Elements: []object.Object{
+					&object.Integer{Value: int64(i)}, elem,
+				}}
+			}
+			return &object.Array{Elements: pairs}
+		},
+	},
+	"keys": &object.Builtin{
+		Fn: func(e object.Evaluator, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(
+					"wrong number of arguments, "+
+						"expected:1, got: %d", len(args),
+				)
+			}
+
+			arg, ok := args[0].(*object.Hash)
+			if !ok {
+				return newError("argument to keys must be hash,"+
+					" got %s", args[0].Type())
+			}
+
+			if err := allocate(e, len(arg.Pairs)); err != nil {
+				return err
+			}
+			pairs := arg.SortedPairs()
+			keys := make([]object.Object, len(pairs))
+			for i, pair := range pairs {
+				keys[i] = pair.Key
+			}
+			return &object.Array{Elements: keys}
+		},
+	},
+	"values": &object.Builtin{
+		Fn: func(e object.Evaluator, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(
+					"wrong number of arguments, "+
+						"expected:1, got: %d", len(args),
+				)
+			}
+
+			arg, ok := args[0].(*object.Hash)
+			if !ok {
+				return newError("argument to values must be hash,"+
+					" got %s", args[0].Type())
+			}
+
+			if err := allocate(e, len(arg.Pairs)); err != nil {
+				return err
+			}
+			pairs := arg.SortedPairs()
+			values := make([]object.Object, len(pairs))
+			for i, pair := range pairs {
+				values[i] = pair.Value
+			}
+			return &object.Array{Elements: values}
+		},
+	},
+	"contains": &object.Builtin{
+		Fn: func(e object.Evaluator, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError(
+					"wrong number of arguments, "+
+						"expected:2, got: %d", len(args),
+				)
+			}
+
+			switch arg := args[0].(type) {
+
+			case *object.Array:
+				for _, elem := range arg.Elements {
+					if equalObjects(elem, args[1]) {
+						return TRUE
+					}
+				}
+				return FALSE
+			case *object.Hash:
+				key, ok := args[1].(object.Hashable)
+				if !ok {
+					return newError("Unhashable type %s "+
+						"used as index", args[1].Type())
+				}
+				_, ok = arg.Pairs[key.HashKey()]
+				return nativeBoolean(ok)
+			case *object.String:
+				sub, ok := args[1].(*object.String)
+				if !ok {
+					return newError("argument to contains must be string"+
+						" when searching a string, got %s", args[1].Type())
+				}
+				return nativeBoolean(strings.Contains(arg.Value, sub.Value))
+			default:
+				return newError(
+					"argument to `contains` not supported, got: "+
+						"%s", arg.Type(),
+				)
+			}
+		},
+	},
+	"reverse": &object.Builtin{
+		Fn: func(e object.Evaluator, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(
+					"wrong number of arguments, "+
+						"expected:1, got: %d", len(args),
+				)
+			}
+
+			switch arg := args[0].(type) {
+
+			case *object.Array:
+				n := len(arg.Elements)
+				if err := allocate(e, n); err != nil {
+					return err
+				}
+				reversed := make([]object.Object, n)
+				for i, elem := range arg.Elements {
+					reversed[n-1-i] = elem
+				}
+				return &object.Array{Elements: reversed}
+			case *object.String:
+				if err := allocateString(e, len(arg.Value)); err != nil {
+					return err
+				}
+				runes := []rune(arg.Value)
+				for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+					runes[i], runes[j] = runes[j], runes[i]
+				}
+				return &object.String{Value: string(runes)}
+			default:
+				return newError(
+					"argument to `reverse` not supported, got: "+
+						"%s", arg.Type(),
+				)
+			}
+		},
+	},
+	"join": &object.Builtin{
+		Fn: func(e object.Evaluator, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError(
+					"wrong number of arguments, "+
+						"expected:1 or 2, got: %d", len(args),
+				)
+			}
+
+			arg, ok := args[0].(*object.Array)
+			if !ok {
+				return newError("argument to join must be array,"+
+					" got %s", args[0].Type())
+			}
+			sep := ""
+			if len(args) == 2 {
+				s, ok := args[1].(*object.String)
+				if !ok {
+					return newError("separator to join must be string,"+
+						" got %s", args[1].Type())
+				}
+				sep = s.Value
+			}
+
+			parts := make([]string, len(arg.Elements))
+			size := 0
+			if len(parts) > 1 {
+				size = len(sep) * (len(parts) - 1)
+			}
+			for i, elem := range arg.Elements {
+				parts[i] = toText(elem)
+				size += len(parts[i])
+			}
+			if err := allocateString(e, size); err != nil {
+				return err
+			}
+			return &object.String{Value: strings.Join(parts, sep)}
+		},
+	},
+	"split": &object.Builtin{
+		Fn: func(e object.Evaluator, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError(
+					"wrong number of arguments, "+
+						"expected:1 or 2, got: %d", len(args),
+				)
+			}
+
+			arg, ok := args[0].(*object.String)
+			if !ok {
+				return newError("argument to split must be string,"+
+					" got %s", args[0].Type())
+			}
+
+			// Without a separator the string is split around runs of
+			// white space, and an empty separator splits it into
+			// characters.
+			var parts []string
+			if len(args) == 1 {
+				parts = strings.Fields(arg.Value)
+			} else {
+				sep, ok := args[1].(*object.String)
+				if !ok {
+					return newError("separator to split must be string,"+
+						" got %s", args[1].Type())
+				}
+				parts = strings.Split(arg.Value, sep.Value)
+			}
+
+			if err := allocate(e, len(parts)); err != nil {
+				return err
+			}
+			elements := make([]object.Object, len(parts))
+			for i, part := range parts {
+				if err := allocateString(e, len(part)); err != nil {
+					return err
+				}
+				elements[i] = &object.String{Value: part}
+			}
+			return &object.Array{Elements: elements}
+		},
+	},
+}
+
+// rangeLength returns how many values range(start, end, step) takes,
+// or false when there are more than an array can hold.
+func rangeLength(start, end, step int64) (int, bool) {
+
+	var span, stride uint64
+	switch {
+	case step > 0 && start < end:
+		span, stride = uint64(end)-uint64(start), uint64(step)
+	case step < 0 && start > end:
+		span, stride = uint64(start)-uint64(end), -uint64(step)
+	default:
+		return 0, true
+	}
+
+	n := (span-1)/stride + 1
+	if n > math.MaxInt {
+		return 0, false
+	}
+	return int(n), true
+}
+
+// equalObjects reports whether a == b holds. Values that can't be
+// compared with == are never equal.
+func equalObjects(a, b object.Object) bool {
+	return a == b || evalInfixExpression("==", a, b) == TRUE
+}
+
+// toText returns a string as it is and any other value as it would be
+// printed.
+func toText(obj object.Object) string {
+	if s, ok := obj.(*object.String); ok {
+		return s.Value
+	}
+	return obj.Inspect()
+}
+
+// appendElements adds the values after the first argument to the end
+// of the array in place and returns the array.
+func appendElements(e object.Evaluator, name string, args []object.Object) object.Object {
+
+	if len(args) < 2 {
+		return newError(
+			"wrong number of arguments, "+
+				"expected:at least 2, got: %d", len(args),
+		)
+	}
+
+	arg, ok := args[0].(*object.Array)
+	if !ok {
+		return newError("argument to %s must be array,"+
+			" got %s", name, args[0].Type())
+	}
+
+	if err := allocate(e, len(args)-1); err != nil {
+		return err
+	}
+	arg.Elements = append(arg.Elements, args[1:]...)
+	return arg
 }
@@ -3,131 +3,305 @@ package eval
 import (
 	"camel/ast"
 	"camel/object"
+	"camel/token"
+	"context"
 	"fmt"
+	"math"
+	"strings"
+	"time"
+	"unicode/utf8"
 )
 
 var (
 	NULL  = &object.Null{}
 	TRUE  = &object.Boolean{Value: true}
 	FALSE = &object.Boolean{Value: false}
+
+	BREAK    = &object.Break{}
+	CONTINUE = &object.Continue{}
 )
 
+// Evaluator walks the AST. It keeps the stack of camel function calls
+// in progress so runtime errors can report how they were reached, and
+// its own set of builtins.
+type Evaluator struct {
+	frames   []object.Frame
+	builtins map[string]*object.Builtin
+
+	// callPos is the position of the innermost builtin call, used for
+	// the frames of functions the builtin calls back into.
+	callPos token.Position
+
+	limits Limits
+	// ctx is the context of the evaluation in progress, or nil when
+	// the evaluator is idle.
+	ctx      context.Context
+	deadline time.Time
+	steps    int64
+	elements int64
+	bytes    int64
+}
+
+// New returns an Evaluator with the standard builtins.
+func New() *Evaluator {
+
+	e := &Evaluator{builtins: make(map[string]*object.Builtin, len(builtins))}
+	for name, builtin := range builtins {
+		e.builtins[name] = builtin
+	}
+	return e
+}
+
+// Register makes builtin available to programs run by e under name,
+// replacing any builtin of that name. Variables still hide builtins.
+func (e *Evaluator) Register(name string, builtin *object.Builtin) {
+	e.builtins[name] = builtin
+}
+
+// Unregister removes the builtin called name from e, so programs can
+// no longer call it.
+func (e *Evaluator) Unregister(name string) {
+	delete(e.builtins, name)
+}
+
+// Eval evaluates node with a fresh Evaluator.
 func Eval(node ast.Node, env *object.Environment) object.Object {
+	return New().Eval(node, env)
+}
+
+// Eval evaluates node in env without a context; see EvalContext.
+func (e *Evaluator) Eval(node ast.Node, env *object.Environment) object.Object {
+	return e.EvalContext(context.Background(), node, env)
+}
+
+func (e *Evaluator) eval(node ast.Node, env *object.Environment) object.Object {
+
+	var result object.Object
+	if err := e.step(); err != nil {
+		result = err
+	} else {
+		result = e.evalNode(node, env)
+	}
+
+	// The innermost node an error comes out of is the most precise
+	// location we have for it, so only the first one is kept.
+	if err, ok := result.(*object.Error); ok && !err.Pos.IsValid() {
+		err.Pos = node.Pos()
+		err.Stack = e.stack()
+	}
+	return result
+}
+
+// Apply calls fn with args. Builtins use it to call camel functions
+// passed to them, such as the callback of map.
+func (e *Evaluator) Apply(fn object.Object, args ...object.Object) object.Object {
+	return e.ApplyContext(context.Background(), fn, args...)
+}
+
+// ApplyContext calls fn with args under ctx and e's limits, like
+// EvalContext. It is Apply for callers outside a running evaluation.
+func (e *Evaluator) ApplyContext(
+	ctx context.Context,
+	fn object.Object,
+	args ...object.Object,
+) object.Object {
+	return e.run(ctx, func() object.Object {
+		return e.applyFunction(fn, args, e.callPos)
+	})
+}
+
+// stack returns a copy of the call stack, innermost call first.
+func (e *Evaluator) stack() []object.Frame {
+
+	stack := make([]object.Frame, len(e.frames))
+	for i, frame := range e.frames {
+		stack[len(e.frames)-1-i] = frame
+	}
+	return stack
+}
+
+func (e *Evaluator) evalNode(node ast.Node, env *object.Environment) object.Object {
 
 	switch node := node.(type) {
 
 	case *ast.Program:
-		return evalProgram(node, env)
+		return e.evalProgram(node, env)
 
 	case *ast.BlockStatement:
-		return evalBlockStatement(node, env)
+		return e.evalBlockStatement(node, env)
 
 	case *ast.ExpressionStatement:
-		return Eval(node.Expression, env)
+		return e.eval(node.Expression, env)
 
 	case *ast.FunctionLiteral:
 
 		params := node.Parameters
 		body := node.Body
 
-		return &object.Function{Parameters: params, Body: body, Env: env}
+		return &object.Function{
+			Name:       node.Name,
+			Parameters: params,
+			Body:       body,
+			Env:        env,
+		}
 
 	case *ast.CallExpression:
 
-		function := Eval(node.Function, env)
-		if isError(function) {
+		function := e.eval(node.Function, env)
+		if isSignal(function) {
 			return function
 		}
+		if node.Optional && function == NULL {
+			return NULL
+		}
 
-		args := evalExpressions(node.Arguments, env)
-		if len(args) == 1 && isError(args[0]) {
+		args := e.evalExpressions(node.Arguments, env)
+		if len(args) == 1 && isSignal(args[0]) {
 			return args[0]
 		}
 
-		return applyFunction(function, args)
+		// A tail call is left to the function being returned from, but
+		// one that is bound to fail is made here so the error points
+		// at it.
+		fn, ok := function.(*object.Function)
+		if node.Tail && ok && len(args) == len(fn.Parameters) {
+			return &object.TailCall{Function: fn, Arguments: args, Pos: node.Pos()}
+		}
+
+		return e.applyFunction(function, args, node.Pos())
 
 	case *ast.ArrayLiteral:
-		elements := evalExpressions(node.Elements, env)
-		if len(elements) == 1 && isError(elements[0]) {
+		elements := e.evalExpressions(node.Elements, env)
+		if len(elements) == 1 && isSignal(elements[0]) {
 			return elements[0]
 		}
+		if err := e.allocate(len(elements)); err != nil {
+			return err
+		}
 		return &object.Array{Elements: elements}
 
 	case *ast.HashLiteral:
-		return evalHashLiteral(node, env)
+		return e.evalHashLiteral(node, env)
 
 	case *ast.IndexExpression:
-		left := Eval(node.Left, env)
-		if isError(left) {
+		left := e.eval(node.Left, env)
+		if isSignal(left) {
 			return left
 		}
+		if node.Optional && left == NULL {
+			return NULL
+		}
 
-		index := Eval(node.Index, env)
-		if isError(index) {
+		index := e.eval(node.Index, env)
+		if isSignal(index) {
 			return index
 		}
 
 		return evalIndexExpression(left, index)
 
+	case *ast.SliceExpression:
+		return e.evalSliceExpression(node, env)
+
 	case *ast.PrefixExpression:
-		right := Eval(node.Right, env)
-		if isError(right) {
+		right := e.eval(node.Right, env)
+		if isSignal(right) {
 			return right
 		}
 		return evalPrefixExpression(node.Operator, right)
 
 	case *ast.InfixExpression:
-		left := Eval(node.Left, env)
-		if isError(left) {
+		left := e.eval(node.Left, env)
+		if isSignal(left) {
 			return left
 		}
 
-		right := Eval(node.Right, env)
-		if isError(right) {
+		if node.Operator == "&&" || node.Operator == "||" {
+			return e.evalLogicalExpression(node.Operator, left, node.Right, env)
+		}
+		if node.Operator == "??" {
+			// The right side is only evaluated when it is needed.
+			if left != NULL {
+				return left
+			}
+			return e.eval(node.Right, env)
+		}
+
+		right := e.eval(node.Right, env)
+		if isSignal(right) {
 			return right
 		}
-		return evalInfixExpression(node.Operator, left, right)
+		return e.evalInfix(node.Operator, left, right)
 
 	case *ast.IfExpression:
-		return evalIfExpression(node, env)
+		return e.evalIfExpression(node, env)
+
+	case *ast.AssignExpression:
+		return e.evalAssignExpression(node, env)
+
+	case *ast.WhileStatement:
+		return e.evalWhileStatement(node, env)
+
+	case *ast.ForStatement:
+		return e.evalForStatement(node, env)
+
+	case *ast.ForInStatement:
+		return e.evalForInStatement(node, env)
+
+	case *ast.BreakStatement:
+		return BREAK
+
+	case *ast.ContinueStatement:
+		return CONTINUE
 
 	case *ast.IntegerLiteral:
 		return &object.Integer{Value: node.Value}
 
+	case *ast.FloatLiteral:
+		return &object.Float{Value: node.Value}
+
+	case *ast.InterpolatedString:
+		return e.evalInterpolatedString(node, env)
+
 	case *ast.StringLiteral:
 		return &object.String{Value: node.Value}
 
 	case *ast.Boolean:
 		return nativeBoolean(node.Value)
 
+	case *ast.NullLiteral:
+		return NULL
+
 	case *ast.LetStatement:
-		val := Eval(node.Value, env)
-		if isError(val) {
+		val := e.eval(node.Value, env)
+		if isSignal(val) {
 			return val
 		}
 		env.Set(node.Name.Value, val)
 
 	case *ast.Identifier:
-		return evalIdentifier(node, env)
+		return e.evalIdentifier(node, env)
 
 	case *ast.ReturnStatement:
-		val := Eval(node.ReturnValue, env)
+		val := e.eval(node.ReturnValue, env)
+		if isSignal(val) {
+			return val
+		}
 		return &object.ReturnValue{Value: val}
 
 	}
 	return nil
 }
 
-func evalExpressions(
+func (e *Evaluator) evalExpressions(
 	exps []ast.Expression,
 	env *object.Environment,
 ) []object.Object {
 
 	var objs []object.Object
 
-	for _, e := range exps {
-		evaluated := Eval(e, env)
-		if isError(evaluated) {
+	for _, exp := range exps {
+		evaluated := e.eval(exp, env)
+		if isSignal(evaluated) {
 			return []object.Object{evaluated}
 		}
 		objs = append(objs, evaluated)
@@ -136,20 +310,50 @@ func evalExpressions(
 	return objs
 }
 
-func applyFunction(
+func (e *Evaluator) applyFunction(
 	f object.Object,
 	args []object.Object,
+	pos token.Position,
 ) object.Object {
 
 	switch fn := f.(type) {
 
 	case *object.Function:
-		extendedEnv := extendFunctionEnv(fn, args)
-		evaluated := Eval(fn.Body, extendedEnv)
-		return unwrapReturnValue(evaluated)
+		if len(args) != len(fn.Parameters) {
+			return newError("wrong number of arguments, "+
+				"expected:%d, got: %d", len(fn.Parameters), len(args))
+		}
+		if max := e.limits.maxDepth(); max > 0 && len(e.frames) >= max {
+			return limitError("maximum recursion depth exceeded")
+		}
+		e.frames = append(e.frames, object.Frame{Function: fn.Name, Pos: pos})
+		defer func() { e.frames = e.frames[:len(e.frames)-1] }()
+
+		// Tail calls made by the body are run here in a loop, each
+		// taking over the frame of the call it replaces and counting
+		// it there.
+		for {
+			extendedEnv := extendFunctionEnv(fn, args)
+			evaluated := unwrapReturnValue(e.eval(fn.Body, extendedEnv))
+
+			tail, ok := evaluated.(*object.TailCall)
+			if !ok {
+				return evaluated
+			}
+			fn, args = tail.Function, tail.Arguments
+			top := &e.frames[len(e.frames)-1]
+			*top = object.Frame{Function: fn.Name, Pos: tail.Pos, TailCalls: top.TailCalls + 1}
+		}
 
 	case *object.Builtin:
-		return fn.Fn(args...)
+		outer := e.callPos
+		e.callPos = pos
+		result := fn.Fn(e, args...)
+		e.callPos = outer
+		if result == nil {
+			return NULL
+		}
+		return result
 
 	default:
 		return newError("Invalid function call, %s is not a function", f.Type())
@@ -157,10 +361,15 @@ func applyFunction(
 	}
 }
 
+// unwrapReturnValue gives the value a function body evaluated to. A
+// body that ends in a statement with no value, such as let, gives null.
 func unwrapReturnValue(obj object.Object) object.Object {
 	if retVal, ok := obj.(*object.ReturnValue); ok {
 		return retVal.Value
 	}
+	if obj == nil {
+		return NULL
+	}
 	return obj
 }
 
@@ -185,6 +394,9 @@ func evalIndexExpression(
 	case left.Type() == object.ARRAY_OBJ &&
 		index.Type() == object.INTEGER_OBJ:
 		return evalArrayIndexExpression(left, index)
+	case left.Type() == object.STRING_OBJ &&
+		index.Type() == object.INTEGER_OBJ:
+		return evalStringIndexExpression(left, index)
 	case left.Type() == object.HASH_OBJ:
 		return evalHashIndexExpression(left, index)
 	default:
@@ -193,6 +405,123 @@ func evalIndexExpression(
 	}
 }
 
+func (e *Evaluator) evalAssignExpression(
+	node *ast.AssignExpression,
+	env *object.Environment,
+) object.Object {
+
+	// Compound assignments such as += apply the operator without the
+	// trailing '='.
+	operator := strings.TrimSuffix(node.Operator, "=")
+
+	switch target := node.Target.(type) {
+
+	case *ast.Identifier:
+		var current object.Object
+		if operator != "" {
+			current = e.evalIdentifier(target, env)
+			if isError(current) {
+				return current
+			}
+		}
+
+		value := e.eval(node.Value, env)
+		if isSignal(value) {
+			return value
+		}
+
+		if current != nil {
+			value = e.evalInfix(operator, current, value)
+			if isError(value) {
+				return value
+			}
+		}
+
+		if !env.Assign(target.Value, value) {
+			return newError("Cannot assign to undeclared identifier: %s",
+				target.Value)
+		}
+		return value
+
+	case *ast.IndexExpression:
+		left := e.eval(target.Left, env)
+		if isSignal(left) {
+			return left
+		}
+
+		index := e.eval(target.Index, env)
+		if isSignal(index) {
+			return index
+		}
+
+		var current object.Object
+		if operator != "" {
+			current = evalIndexExpression(left, index)
+			if isError(current) {
+				return current
+			}
+		}
+
+		value := e.eval(node.Value, env)
+		if isSignal(value) {
+			return value
+		}
+
+		if current != nil {
+			value = e.evalInfix(operator, current, value)
+			if isError(value) {
+				return value
+			}
+		}
+
+		return e.evalIndexAssignment(left, index, value)
+
+	default:
+		return newError("Invalid assignment: cannot assign to %s", node.Target)
+	}
+}
+
+func (e *Evaluator) evalIndexAssignment(
+	left object.Object,
+	index object.Object,
+	value object.Object,
+) object.Object {
+
+	switch left := left.(type) {
+
+	case *object.Array:
+		id, ok := index.(*object.Integer)
+		if !ok {
+			return newError("Invalid Index: array index must be INTEGER, got %s",
+				index.Type())
+		}
+		i, ok := resolveIndex(id.Value, int64(len(left.Elements)))
+		if !ok {
+			return indexOutOfRange(id.Value, int64(len(left.Elements)))
+		}
+		left.Elements[i] = value
+		return value
+
+	case *object.Hash:
+		hashKey, ok := index.(object.Hashable)
+		if !ok {
+			return newError("Unhashable type %s "+
+				"used as index", index.Type())
+		}
+		if _, ok := left.Pairs[hashKey.HashKey()]; !ok {
+			if err := e.allocate(1); err != nil {
+				return err
+			}
+		}
+		left.Pairs[hashKey.HashKey()] = object.HashPair{Key: index, Value: value}
+		return value
+
+	default:
+		return newError("Invalid Index: index assignment not "+
+			"supported for type %s", left.Type())
+	}
+}
+
 func evalArrayIndexExpression(
 	array object.Object,
 	index object.Object,
@@ -200,13 +529,141 @@ func evalArrayIndexExpression(
 
 	arrayObj := array.(*object.Array)
 	id := index.(*object.Integer).Value
-	max := int64(len(arrayObj.Elements))
 
-	if id < 0 || id > max {
-		return newError("Index out of range")
+	i, ok := resolveIndex(id, int64(len(arrayObj.Elements)))
+	if !ok {
+		return indexOutOfRange(id, int64(len(arrayObj.Elements)))
+	}
+
+	return arrayObj.Elements[i]
+}
+
+// resolveIndex turns a negative index, which counts from the end, into
+// the offset it stands for and reports whether the result lies in
+// [0, length).
+func resolveIndex(index, length int64) (int64, bool) {
+
+	if index < 0 {
+		index += length
 	}
+	return index, index >= 0 && index < length
+}
 
-	return arrayObj.Elements[id]
+func indexOutOfRange(index, length int64) *object.Error {
+	return newError("Index out of range: index %d, length %d", index, length)
+}
+
+// evalStringIndexExpression returns the character at the index,
+// counting in code points rather than bytes.
+func evalStringIndexExpression(
+	str object.Object,
+	index object.Object,
+) object.Object {
+
+	value := str.(*object.String).Value
+	id := index.(*object.Integer).Value
+	length := int64(utf8.RuneCountInString(value))
+
+	i, ok := resolveIndex(id, length)
+	if !ok {
+		return indexOutOfRange(id, length)
+	}
+
+	for _, char := range value {
+		if i == 0 {
+			return &object.String{Value: string(char)}
+		}
+		i--
+	}
+	return NULL
+}
+
+func (e *Evaluator) evalSliceExpression(
+	node *ast.SliceExpression,
+	env *object.Environment,
+) object.Object {
+
+	left := e.eval(node.Left, env)
+	if isSignal(left) {
+		return left
+	}
+	if node.Optional && left == NULL {
+		return NULL
+	}
+
+	var length int64
+	switch left := left.(type) {
+	case *object.Array:
+		length = int64(len(left.Elements))
+	case *object.String:
+		length = int64(utf8.RuneCountInString(left.Value))
+	default:
+		return newError("Invalid Index: slice operator not "+
+			"supported for type %s", left.Type())
+	}
+
+	low, err := e.evalSliceBound(node.Low, 0, length, env)
+	if err != nil {
+		return err
+	}
+	high, err := e.evalSliceBound(node.High, length, length, env)
+	if err != nil {
+		return err
+	}
+	if high < low {
+		high = low
+	}
+
+	// The result is a copy, so changing it in place leaves the original
+	// alone.
+	switch left := left.(type) {
+	case *object.Array:
+		if err := e.allocate(int(high - low)); err != nil {
+			return err
+		}
+		elements := make([]object.Object, high-low)
+		copy(elements, left.Elements[low:high])
+		return &object.Array{Elements: elements}
+	default:
+		runes := []rune(left.(*object.String).Value)
+		return &object.String{Value: string(runes[low:high])}
+	}
+}
+
+// evalSliceBound evaluates one side of a slice. A missing bound takes
+// the given default, a negative one counts from the end, and the result
+// is clamped to the length of what is being sliced.
+func (e *Evaluator) evalSliceBound(
+	node ast.Expression,
+	missing, length int64,
+	env *object.Environment,
+) (int64, object.Object) {
+
+	if node == nil {
+		return missing, nil
+	}
+
+	val := e.eval(node, env)
+	if isSignal(val) {
+		return 0, val
+	}
+	bound, ok := val.(*object.Integer)
+	if !ok {
+		return 0, newError("Invalid Index: slice index must be "+
+			"INTEGER, got %s", val.Type())
+	}
+
+	i := bound.Value
+	if i < 0 {
+		i += length
+	}
+	if i < 0 {
+		return 0, nil
+	}
+	if i > length {
+		return length, nil
+	}
+	return i, nil
 }
 
 func evalHashIndexExpression(
@@ -230,7 +687,7 @@ func evalHashIndexExpression(
 	return p.Value
 }
 
-func evalHashLiteral(
+func (e *Evaluator) evalHashLiteral(
 	node *ast.HashLiteral,
 	env *object.Environment,
 ) object.Object {
@@ -239,8 +696,8 @@ func evalHashLiteral(
 
 	for k, v := range node.Pairs {
 
-		key := Eval(k, env)
-		if isError(key) {
+		key := e.eval(k, env)
+		if isSignal(key) {
 			return key
 		}
 
@@ -249,8 +706,8 @@ func evalHashLiteral(
 			return newError("Object %s not hashable", key.Type())
 		}
 
-		value := Eval(v, env)
-		if isError(value) {
+		value := e.eval(v, env)
+		if isSignal(value) {
 			return value
 		}
 
@@ -258,10 +715,13 @@ func evalHashLiteral(
 			Value: value}
 	}
 
+	if err := e.allocate(len(pairs)); err != nil {
+		return err
+	}
 	return &object.Hash{Pairs: pairs}
 }
 
-func evalIdentifier(
+func (e *Evaluator) evalIdentifier(
 	node *ast.Identifier,
 	env *object.Environment,
 ) object.Object {
@@ -270,32 +730,160 @@ func evalIdentifier(
 		return val
 	}
 
-	if builtin, ok := builtins[node.Value]; ok {
+	if builtin, ok := e.builtins[node.Value]; ok {
 		return builtin
 	}
 
-	fmt.Println(env)
 	return newError("Identifier not found: %s", node.Value)
 
 }
-func evalIfExpression(
+func (e *Evaluator) evalIfExpression(
 	ie *ast.IfExpression,
 	env *object.Environment,
 ) object.Object {
 
-	condition := Eval(ie.Condition, env)
+	condition := e.eval(ie.Condition, env)
 
-	if isError(condition) {
+	if isSignal(condition) {
 		return condition
 	}
 
+	var result object.Object
 	if isTrue(condition) {
-		return Eval(ie.Consequence, env)
+		result = e.eval(ie.Consequence, env)
 	} else if ie.Alternative != nil {
-		return Eval(ie.Alternative, env)
-	} else {
+		result = e.eval(ie.Alternative, env)
+	}
+
+	// A branch ending in a statement such as let has no value either.
+	if result == nil {
 		return NULL
 	}
+	return result
+}
+
+func (e *Evaluator) evalWhileStatement(
+	ws *ast.WhileStatement,
+	env *object.Environment,
+) object.Object {
+
+	for {
+		condition := e.eval(ws.Condition, env)
+		if isSignal(condition) {
+			return condition
+		}
+		if !isTrue(condition) {
+			return NULL
+		}
+
+		result := e.eval(ws.Body, env)
+		if done, value := loopDone(result); done {
+			return value
+		}
+	}
+}
+
+func (e *Evaluator) evalForStatement(
+	fs *ast.ForStatement,
+	env *object.Environment,
+) object.Object {
+
+	if fs.Init != nil {
+		init := e.eval(fs.Init, env)
+		if isSignal(init) {
+			return init
+		}
+	}
+
+	for {
+		if fs.Condition != nil {
+			condition := e.eval(fs.Condition, env)
+			if isSignal(condition) {
+				return condition
+			}
+			if !isTrue(condition) {
+				return NULL
+			}
+		}
+
+		result := e.eval(fs.Body, env)
+		if done, value := loopDone(result); done {
+			return value
+		}
+
+		if fs.Post != nil {
+			post := e.eval(fs.Post, env)
+			if isSignal(post) {
+				return post
+			}
+		}
+	}
+}
+
+func (e *Evaluator) evalForInStatement(
+	fs *ast.ForInStatement,
+	env *object.Environment,
+) object.Object {
+
+	iterable := e.eval(fs.Iterable, env)
+	if isSignal(iterable) {
+		return iterable
+	}
+
+	items, err := iterate(iterable)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		env.Set(fs.Variable.Value, item)
+
+		result := e.eval(fs.Body, env)
+		if done, value := loopDone(result); done {
+			return value
+		}
+	}
+	return NULL
+}
+
+// loopDone reports whether the result of a loop body ends the loop,
+// and if so what the loop evaluates to.
+func loopDone(result object.Object) (bool, object.Object) {
+
+	switch result := result.(type) {
+	case *object.Break:
+		return true, NULL
+	case *object.ReturnValue, *object.Error:
+		return true, result
+	default:
+		return false, nil
+	}
+}
+
+// iterate returns the elements a for-in loop visits: array elements,
+// hash keys or the characters of a string.
+func iterate(obj object.Object) ([]object.Object, *object.Error) {
+
+	switch obj := obj.(type) {
+	case *object.Array:
+		return obj.Elements, nil
+	case *object.Hash:
+		pairs := obj.SortedPairs()
+		keys := make([]object.Object, len(pairs))
+		for i, p := range pairs {
+			keys[i] = p.Key
+		}
+		return keys, nil
+	case *object.String:
+		chars := []object.Object{}
+		for _, char := range obj.Value {
+			chars = append(chars, &object.String{Value: string(char)})
+		}
+		return chars, nil
+	default:
+		return nil, newError("Invalid iteration: cannot iterate over %s",
+			obj.Type())
+	}
 }
 
 func isTrue(obj object.Object) bool {
@@ -329,6 +917,8 @@ func evalPrefixExpression(
 		return evalBangOperatorExpression(obj)
 	case "-":
 		return evalMinusPrefixOperator(obj)
+	case "~":
+		return evalBitNotPrefixOperator(obj)
 	default:
 		return newError("Unknown operator: operator %s is not a valid prefix operator", operator)
 	}
@@ -339,8 +929,12 @@ func evalBangOperatorExpression(obj object.Object) object.Object {
 	switch obj := obj.(type) {
 	case *object.Integer:
 		return evalBangInteger(obj)
+	case *object.Float:
+		return nativeBoolean(obj.Value == 0)
 	case *object.Boolean:
 		return evalBangBoolean(obj)
+	case *object.Null:
+		return TRUE
 	default:
 		return FALSE
 	}
@@ -363,14 +957,68 @@ func evalBangBoolean(boolean *object.Boolean) *object.Boolean {
 func evalMinusPrefixOperator(
 	obj object.Object,
 ) object.Object {
+	if f, ok := obj.(*object.Float); ok {
+		return &object.Float{Value: -f.Value}
+	}
 	if obj.Type() != object.INTEGER_OBJ {
 		return newError("Invalid operator: type %s doesn't support '-' operator", obj.Type())
 	}
 
 	val := obj.(*object.Integer).Value
+	if val == math.MinInt64 {
+		return newError("Integer overflow: -(%d)", val)
+	}
 	return &object.Integer{Value: -val}
 }
 
+// evalLogicalExpression only evaluates the right operand when the left
+// one doesn't already decide the result.
+func (e *Evaluator) evalLogicalExpression(
+	operator string,
+	left object.Object,
+	rightNode ast.Expression,
+	env *object.Environment,
+) object.Object {
+
+	switch {
+	case operator == "&&" && !isTrue(left):
+		return FALSE
+	case operator == "||" && isTrue(left):
+		return TRUE
+	}
+
+	right := e.eval(rightNode, env)
+	if isSignal(right) {
+		return right
+	}
+	return nativeBoolean(isTrue(right))
+}
+
+func evalBitNotPrefixOperator(
+	obj object.Object,
+) object.Object {
+	if obj.Type() != object.INTEGER_OBJ {
+		return newError("Invalid operator: type %s doesn't support '~' operator", obj.Type())
+	}
+
+	val := obj.(*object.Integer).Value
+	return &object.Integer{Value: ^val}
+}
+
+// evalInfix applies an infix operator for the running program, first
+// counting the bytes of a string concatenation against e's limits.
+func (e *Evaluator) evalInfix(operator string, left, right object.Object) object.Object {
+
+	l, ok := left.(*object.String)
+	r, rok := right.(*object.String)
+	if operator == "+" && ok && rok {
+		if err := e.allocateString(len(l.Value) + len(r.Value)); err != nil {
+			return err
+		}
+	}
+	return evalInfixExpression(operator, left, right)
+}
+
 func evalInfixExpression(
 	operator string,
 	left, right object.Object,
@@ -382,6 +1030,15 @@ func evalInfixExpression(
 		right.Type() == object.INTEGER_OBJ:
 		return parseIntegerInfixExpression(operator, left, right)
 
+	// Any value can be compared with null.
+	case (left == NULL || right == NULL) && operator == "==":
+		return nativeBoolean(left == right)
+	case (left == NULL || right == NULL) && operator == "!=":
+		return nativeBoolean(left != right)
+
+	case isNumber(left) && isNumber(right):
+		return parseFloatInfixExpression(operator, toFloat(left), toFloat(right))
+
 	case left.Type() == object.BOOLEAN_OBJ &&
 		right.Type() == object.BOOLEAN_OBJ:
 		return parseBooleanInfixExpression(operator, left, right)
@@ -398,6 +1055,68 @@ func evalInfixExpression(
 	}
 }
 
+func isNumber(obj object.Object) bool {
+	return obj.Type() == object.INTEGER_OBJ || obj.Type() == object.FLOAT_OBJ
+}
+
+func toFloat(obj object.Object) float64 {
+	if i, ok := obj.(*object.Integer); ok {
+		return float64(i.Value)
+	}
+	return obj.(*object.Float).Value
+}
+
+// parseFloatInfixExpression handles arithmetic where at least one side
+// is a float; the integer side, if any, has already been promoted.
+func parseFloatInfixExpression(
+	operator string,
+	leftVal, rightVal float64,
+) object.Object {
+
+	switch operator {
+
+	case "+":
+		return &object.Float{Value: leftVal + rightVal}
+	case "-":
+		return &object.Float{Value: leftVal - rightVal}
+	case "*":
+		return &object.Float{Value: leftVal * rightVal}
+	case "/", "%":
+		if rightVal == 0 {
+			return newError("Division by zero: %s %s %s",
+				formatFloat(leftVal), operator, formatFloat(rightVal))
+		}
+		if operator == "%" {
+			return &object.Float{Value: math.Mod(leftVal, rightVal)}
+		}
+		return &object.Float{Value: leftVal / rightVal}
+	case "**":
+		if leftVal == 0 && rightVal < 0 {
+			return newError("Division by zero: %s %s %s",
+				formatFloat(leftVal), operator, formatFloat(rightVal))
+		}
+		return &object.Float{Value: math.Pow(leftVal, rightVal)}
+	case "<":
+		return nativeBoolean(leftVal < rightVal)
+	case ">":
+		return nativeBoolean(leftVal > rightVal)
+	case "<=":
+		return nativeBoolean(leftVal <= rightVal)
+	case ">=":
+		return nativeBoolean(leftVal >= rightVal)
+	case "==":
+		return nativeBoolean(leftVal == rightVal)
+	case "!=":
+		return nativeBoolean(leftVal != rightVal)
+	default:
+		return newError("Unknown operator: no %s operator registered for FLOAT", operator)
+	}
+}
+
+func formatFloat(f float64) string {
+	return (&object.Float{Value: f}).Inspect()
+}
+
 func parseStringInfixExpression(
 	operator string,
 	left, right object.Object,
@@ -410,6 +1129,18 @@ func parseStringInfixExpression(
 
 	case "+":
 		return &object.String{Value: leftVal + rightVal}
+	case "<":
+		return nativeBoolean(leftVal < rightVal)
+	case ">":
+		return nativeBoolean(leftVal > rightVal)
+	case "<=":
+		return nativeBoolean(leftVal <= rightVal)
+	case ">=":
+		return nativeBoolean(leftVal >= rightVal)
+	case "==":
+		return nativeBoolean(leftVal == rightVal)
+	case "!=":
+		return nativeBoolean(leftVal != rightVal)
 	default:
 		return newError("Unknown operator: no %s operator registered for Strings", operator)
 	}
@@ -426,18 +1157,27 @@ func parseIntegerInfixExpression(
 
 	switch operator {
 
-	case "+":
-		return &object.Integer{Value: leftVal + rightVal}
-	case "-":
-		return &object.Integer{Value: leftVal - rightVal}
-	case "/":
-		return &object.Integer{Value: leftVal / rightVal}
-	case "*":
-		return &object.Integer{Value: leftVal * rightVal}
+	case "+", "-", "*", "/", "%", "**", "<<":
+		return evalIntegerArithmetic(operator, leftVal, rightVal)
+	case "&":
+		return &object.Integer{Value: leftVal & rightVal}
+	case "|":
+		return &object.Integer{Value: leftVal | rightVal}
+	case "^":
+		return &object.Integer{Value: leftVal ^ rightVal}
+	case ">>":
+		if rightVal < 0 {
+			return newError("Invalid shift: negative shift count %d", rightVal)
+		}
+		return &object.Integer{Value: leftVal >> rightVal}
 	case "<":
 		return nativeBoolean(leftVal < rightVal)
 	case ">":
 		return nativeBoolean(leftVal > rightVal)
+	case "<=":
+		return nativeBoolean(leftVal <= rightVal)
+	case ">=":
+		return nativeBoolean(leftVal >= rightVal)
 	case "==":
 		return nativeBoolean(leftVal == rightVal)
 	case "!=":
@@ -447,6 +1187,111 @@ func parseIntegerInfixExpression(
 	}
 }
 
+// evalIntegerArithmetic applies the operators that can fail on
+// integers. Integers are 64 bits wide and never wrap around: a result
+// that doesn't fit is an overflow error.
+func evalIntegerArithmetic(operator string, a, b int64) object.Object {
+
+	var (
+		result int64
+		ok     bool
+	)
+
+	switch operator {
+	case "+":
+		result, ok = addInt(a, b)
+	case "-":
+		result, ok = subInt(a, b)
+	case "*":
+		result, ok = mulInt(a, b)
+	case "/", "%":
+		if b == 0 {
+			return newError("Division by zero: %d %s %d", a, operator, b)
+		}
+		if operator == "%" {
+			return &object.Integer{Value: a % b}
+		}
+		result, ok = a/b, !(a == math.MinInt64 && b == -1)
+	case "**":
+		// A negative power is a reciprocal, which zero doesn't have.
+		if b < 0 && a == 0 {
+			return newError("Division by zero: %d %s %d", a, operator, b)
+		}
+		if b < 0 {
+			return &object.Float{Value: math.Pow(float64(a), float64(b))}
+		}
+		result, ok = powInt(a, b)
+	case "<<":
+		if b < 0 {
+			return newError("Invalid shift: negative shift count %d", b)
+		}
+		result, ok = shlInt(a, b)
+	}
+
+	if !ok {
+		return newError("Integer overflow: %d %s %d", a, operator, b)
+	}
+	return &object.Integer{Value: result}
+}
+
+func addInt(a, b int64) (int64, bool) {
+	c := a + b
+	return c, (c > a) == (b > 0)
+}
+
+func subInt(a, b int64) (int64, bool) {
+	c := a - b
+	return c, (c < a) == (b > 0)
+}
+
+func mulInt(a, b int64) (int64, bool) {
+
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+	if (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return 0, false
+	}
+	c := a * b
+	return c, c/b == a
+}
+
+// powInt raises base to a non-negative exponent by repeated squaring.
+func powInt(base, exp int64) (int64, bool) {
+
+	result := int64(1)
+	for {
+		if exp&1 == 1 {
+			var ok bool
+			if result, ok = mulInt(result, base); !ok {
+				return 0, false
+			}
+		}
+		exp >>= 1
+		if exp == 0 {
+			return result, true
+		}
+		var ok bool
+		if base, ok = mulInt(base, base); !ok {
+			return 0, false
+		}
+	}
+}
+
+// shlInt shifts left, reporting whether any bit, including the sign,
+// was lost.
+func shlInt(a, n int64) (int64, bool) {
+
+	if a == 0 {
+		return 0, true
+	}
+	if n >= 64 {
+		return 0, false
+	}
+	c := a << n
+	return c, c>>n == a
+}
+
 func parseBooleanInfixExpression(
 	operator string,
 	left, right object.Object,
@@ -464,7 +1309,37 @@ func parseBooleanInfixExpression(
 	}
 }
 
-func evalBlockStatement(
+// evalInterpolatedString joins the text of the string with the values
+// of its embedded expressions. Strings are inserted as they are and any
+// other value as it would be printed.
+func (e *Evaluator) evalInterpolatedString(
+	str *ast.InterpolatedString,
+	env *object.Environment,
+) object.Object {
+
+	var out strings.Builder
+
+	for i, part := range str.Parts {
+		if i%2 == 0 {
+			out.WriteString(part.(*ast.StringLiteral).Value)
+			continue
+		}
+
+		val := e.eval(part, env)
+		if isSignal(val) {
+			return val
+		}
+		text := toText(val)
+		if err := e.allocateString(len(text)); err != nil {
+			return err
+		}
+		out.WriteString(text)
+	}
+
+	return &object.String{Value: out.String()}
+}
+
+func (e *Evaluator) evalBlockStatement(
 	block *ast.BlockStatement,
 	env *object.Environment,
 ) object.Object {
@@ -472,20 +1347,16 @@ func evalBlockStatement(
 	var result object.Object
 
 	for _, statement := range block.Statements {
-		result = Eval(statement, env)
+		result = e.eval(statement, env)
 
-		if result != nil {
-			rt := result.Type()
-			if rt == object.ERROR_OBJ ||
-				rt == object.RETURN_VALUE_OBJ {
-				return result
-			}
+		if isSignal(result) {
+			return result
 		}
 	}
 	return result
 }
 
-func evalProgram(
+func (e *Evaluator) evalProgram(
 	program *ast.Program,
 	env *object.Environment,
 ) object.Object {
@@ -493,7 +1364,7 @@ func evalProgram(
 	var result object.Object
 
 	for _, statement := range program.Statements {
-		result = Eval(statement, env)
+		result = e.eval(statement, env)
 
 		switch result := result.(type) {
 
@@ -507,14 +1378,30 @@ func evalProgram(
 }
 
 func newError(format string, a ...interface{}) *object.Error {
-	return &object.Error{Message: fmt.Sprintf(format, a...)}
+	return &object.Error{
+		Kind:    object.RUNTIME_ERROR,
+		Message: fmt.Sprintf(format, a...),
+	}
 }
 
 func isError(obj object.Object) bool {
 
-	if obj.Type() == object.ERROR_OBJ {
+	if obj != nil && obj.Type() == object.ERROR_OBJ {
 		return true
 	} else {
 		return false
 	}
 }
+
+// isSignal reports whether obj has to be passed straight up to the
+// enclosing function or loop instead of being used as a value: an
+// error, a return value, or a break or continue.
+func isSignal(obj object.Object) bool {
+
+	switch obj.(type) {
+	case *object.Error, *object.ReturnValue, *object.Break, *object.Continue:
+		return true
+	default:
+		return false
+	}
+}
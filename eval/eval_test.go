@@ -4,7 +4,11 @@ import (
 	"camel/lexer"
 	"camel/object"
 	"camel/parser"
+	"context"
+	"fmt"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestEvalIntegerExpression(t *testing.T) {
@@ -28,6 +32,30 @@ func TestEvalIntegerExpression(t *testing.T) {
 		{"3 * 3 * 3 + 10", 37},
 		{"3 * (3 * 3) + 10", 37},
 		{"(5 + 10 * 2 + 15 / 3) * 2 + -10", 50},
+		{"7 % 3", 1},
+		{"-7 % 3", -1},
+		{"2 + 10 % 4 * 3", 8},
+		{"2 ** 10", 1024},
+		{"2 ** 3 ** 2", 512},
+		{"-2 ** 2", -4},
+		{"(-2) ** 3", -8},
+		{"5 ** 0", 1},
+		{"6 & 3", 2},
+		{"6 | 3", 7},
+		{"6 ^ 3", 5},
+		{"~5", -6},
+		{"~-1", 0},
+		{"1 << 4", 16},
+		{"256 >> 2", 64},
+		{"-16 >> 2", -4},
+		{"1 + 2 << 1", 6},
+		{"1 | 2 ^ 3 & 1", 3},
+		{"9223372036854775807 - 1 + 1", 9223372036854775807},
+		{"-9223372036854775807 - 1", -9223372036854775807 - 1},
+		{"2 ** 62", 4611686018427387904},
+		{"(-2) ** 63", -9223372036854775807 - 1},
+		{"-1 << 63", -9223372036854775807 - 1},
+		{"(-9223372036854775807 - 1) % -1", 0},
 	}
 
 	for _, tt := range tests {
@@ -37,6 +65,129 @@ func TestEvalIntegerExpression(t *testing.T) {
 	}
 }
 
+func TestEvalFloatExpression(t *testing.T) {
+
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"2.5", 2.5},
+		{"-2.5", -2.5},
+		{"1e3", 1000},
+		{"1.5 + 1.5", 3},
+		{"1.5 + 2", 3.5},
+		{"2 * 0.25", 0.5},
+		{"7 / 2.0", 3.5},
+		{"7.5 % 2", 1.5},
+		{"2.0 ** 0.5 ** 2", 1.189207115002721},
+		{"2 ** -1", 0.5},
+		{"10 ** -2", 0.01},
+		{"let x = 1; x += 0.5; x", 1.5},
+		{"float(2)", 2},
+		{`float("2.5")`, 2.5},
+		{`float(" 1e-3 ")`, 0.001},
+	}
+
+	for _, tt := range tests {
+		testFloatObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestNumberConversion(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"int(7)", 7},
+		{"int(3.9)", 3},
+		{"int(-3.9)", -3},
+		{`int("42")`, 42},
+		{`int("-8")`, -8},
+		{"1 == 1.0", true},
+		{"1 < 1.5", true},
+		{"2.5 >= 3", false},
+		{"0.1 + 0.2 == 0.3", false},
+		{"!0.0", true},
+		{`int("4.5")`, `cannot convert "4.5" to integer`},
+		{"int(1e19)", "cannot convert 10000000000000000000.0 to integer"},
+		{`float("abc")`, `cannot convert "abc" to float`},
+		{"int(true)", "argument to `int` not supported, got: BOOLEAN"},
+		{"float([])", "argument to `float` not supported, got: ARRAY"},
+		{"float(1, 2)", "wrong number of arguments, expected:1, got: 2"},
+		{"1.5 / 0", "Division by zero: 1.5 / 0.0"},
+		{"1 % 0.0", "Division by zero: 1.0 % 0.0"},
+		{"0.0 ** -0.5", "Division by zero: 0.0 ** -0.5"},
+		{"1.5 << 1", "Unknown operator: no << operator registered for FLOAT"},
+		{"1.5 + true", "Type mismatch: invalid operator + for types FLOAT BOOLEAN"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("%q: object is not Error. got=%T (%+v)",
+					tt.input, evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q",
+					expected, errObj.Message)
+			}
+		}
+	}
+}
+
+func TestFloatInspect(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"2.0", "2.0"},
+		{"0.5", "0.5"},
+		{"-3.0", "-3.0"},
+		{"1e3", "1000.0"},
+		{"1e21", "1e+21"},
+		{"1.5e-7", "1.5e-07"},
+		{"0.1 + 0.2", "0.30000000000000004"},
+		{`{"version": 0.0}`, "{version : 0.0}"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%q: expected=%q, got=%q",
+				tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestFloatHashKeys(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"{1: 2}[1.0]", "2"},
+		{"{1.0: 2}[1]", "2"},
+		{"{0: 2}[-0.0]", "2"},
+		{"{0.5: 2}[0.5]", "2"},
+		{"{1: 2}[1.5]", "null"},
+		{"let h = {1: 2}; h[1.0] = 3; h[1]", "3"},
+		{"{-9223372036854775807 - 1: 2}[-9223372036854775808.0]", "2"},
+		{"{9223372036854775807: 2}[9223372036854775808.0]", "null"},
+	}
+
+	for _, tt := range tests {
+		testInspect(t, tt.input, tt.expected)
+	}
+}
+
 func testEval(input string) object.Object {
 	lex := lexer.New(input)
 	p := parser.New(lex)
@@ -70,6 +221,25 @@ func TestEvalBooleanExpression(t *testing.T) {
 		{"(1 < 2) == false", false},
 		{"(1 > 2) == true", false},
 		{"(1 > 2) == false", true},
+		{"1 <= 2", true},
+		{"2 <= 2", true},
+		{"3 <= 2", false},
+		{"1 >= 2", false},
+		{"2 >= 2", true},
+		{`"a" < "b"`, true},
+		{`"b" <= "a"`, false},
+		{`"abc" >= "abc"`, true},
+		{`"a" == "a"`, true},
+		{`"a" != "a"`, false},
+		{"true && true", true},
+		{"true && false", false},
+		{"false || true", true},
+		{"false || false", false},
+		{"1 < 2 && 2 < 3", true},
+		{"1 > 2 || 2 > 3", false},
+		{"false && missing", false},
+		{"true || missing", true},
+		{"let n = 0; let f = fn() { n += 1; true }; false && f(); n == 0", true},
 	}
 
 	for _, tt := range tests {
@@ -135,6 +305,77 @@ if (10 > 1) {
 	}
 }
 
+func TestElseIfExpressions(t *testing.T) {
+	input := `
+let size = fn(x) {
+  if (x < 3) { "small" } else if (x < 10) { "medium" } else if (x < 100) { "large" } else { "huge" }
+};
+[size(1), size(5), size(50), size(500)]`
+
+	evaluated := testEval(input)
+	if evaluated.Inspect() != "[small, medium, large, huge]" {
+		t.Errorf("wrong result. got=%s", evaluated.Inspect())
+	}
+
+	testNullObject(t, testEval("if (false) { 1 } else if (false) { 2 }"))
+}
+
+func TestNullAndOptionalChaining(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"null", nil},
+		{"let x = null; x", nil},
+		{"null == null", true},
+		{"1 == null", false},
+		{`null != "a"`, true},
+		{"[1] == null", false},
+		{"!null", true},
+		{"null ?? 5", 5},
+		{"0 ?? 5", 0},
+		{"false ?? 5", false},
+		{"null ?? null ?? 3", 3},
+		{"1 ?? missing", 1},
+		{`let h = {"a": 1}; h["b"] ?? 2`, 2},
+		{`let h = {"a": {"b": 7}}; h["a"]?["b"]`, 7},
+		{`let h = {"a": 1}; h["x"]?["b"]`, nil},
+		{`let h = {"a": 1}; h["x"]?["b"] ?? 4`, 4},
+		{"let h = null; h?[missing]", nil},
+		{"let f = null; f?(missing)", nil},
+		{"let f = fn(x) { x * 2 }; f?(4)", 8},
+		{`let h = {"f": fn() { 9 }}; h["f"]?()`, 9},
+		{`let h = {}; h["f"]?()`, nil},
+		{"null < 1", "Type mismatch: invalid operator < for types NULL INTEGER"},
+		{"let h = null; h[1]", "Invalid Index: index operator not supported for type NULL"},
+		{"null ?? missing", "Identifier not found: missing"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("%q: object is not Error. got=%T (%+v)",
+					tt.input, evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q",
+					expected, errObj.Message)
+			}
+		default:
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
 func TestReturnStatements(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -199,6 +440,70 @@ if (10 > 1) {
 			"foobar",
 			"Identifier not found: foobar",
 		},
+		{
+			"1 << -1",
+			"Invalid shift: negative shift count -1",
+		},
+		{
+			"8 >> -2",
+			"Invalid shift: negative shift count -2",
+		},
+		{
+			"~true",
+			"Invalid operator: type BOOLEAN doesn't support '~' operator",
+		},
+		{
+			"true & false",
+			"Unknown operator: no & operator registered for BOOLEAN",
+		},
+		{
+			"1 / 0",
+			"Division by zero: 1 / 0",
+		},
+		{
+			"10 % (5 - 5)",
+			"Division by zero: 10 % 0",
+		},
+		{
+			"let x = 4; x /= 0",
+			"Division by zero: 4 / 0",
+		},
+		{
+			"0 ** -1",
+			"Division by zero: 0 ** -1",
+		},
+		{
+			"9223372036854775807 + 1",
+			"Integer overflow: 9223372036854775807 + 1",
+		},
+		{
+			"-9223372036854775807 - 2",
+			"Integer overflow: -9223372036854775807 - 2",
+		},
+		{
+			"3037000500 * 3037000500",
+			"Integer overflow: 3037000500 * 3037000500",
+		},
+		{
+			"(-9223372036854775807 - 1) / -1",
+			"Integer overflow: -9223372036854775808 / -1",
+		},
+		{
+			"-(-9223372036854775807 - 1)",
+			"Integer overflow: -(-9223372036854775808)",
+		},
+		{
+			"2 ** 63",
+			"Integer overflow: 2 ** 63",
+		},
+		{
+			"1 << 63",
+			"Integer overflow: 1 << 63",
+		},
+		{
+			"3 << 64",
+			"Integer overflow: 3 << 64",
+		},
 	}
 
 	for _, tt := range tests {
@@ -218,6 +523,94 @@ if (10 > 1) {
 	}
 }
 
+func TestErrorPositions(t *testing.T) {
+	tests := []struct {
+		input       string
+		expectedPos string
+	}{
+		{"foobar", "1:1"},
+		{"let x = 1;\nlet y = x + true;", "2:9"},
+		{"let f = fn(a) {\n  a + missing\n};\nf(1)", "2:7"},
+		{"[1, 2][5]", "1:1"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T(%+v)",
+				evaluated, evaluated)
+			continue
+		}
+
+		if errObj.Pos.String() != tt.expectedPos {
+			t.Errorf("wrong error position for %q. expected=%s, got=%s",
+				tt.input, tt.expectedPos, errObj.Pos)
+		}
+	}
+}
+
+func TestErrorStackTraces(t *testing.T) {
+	input := `let inner = fn(x) {
+  x + missing
+};
+let outer = fn(x) { inner(x) + 1 };
+outer(1)`
+
+	evaluated := testEval(input)
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+	}
+
+	expected := []object.Frame{
+		{Function: "inner"},
+		{Function: "outer"},
+	}
+	expectedPos := []string{"4:21", "5:1"}
+
+	if len(errObj.Stack) != len(expected) {
+		t.Fatalf("wrong stack depth. want=%d, got=%d (%+v)",
+			len(expected), len(errObj.Stack), errObj.Stack)
+	}
+
+	for i, frame := range errObj.Stack {
+		if frame.Function != expected[i].Function {
+			t.Errorf("frame %d has wrong function. want=%q, got=%q",
+				i, expected[i].Function, frame.Function)
+		}
+		if frame.Pos.String() != expectedPos[i] {
+			t.Errorf("frame %d has wrong position. want=%s, got=%s",
+				i, expectedPos[i], frame.Pos)
+		}
+	}
+
+	expectedInspect := `Error: 2:7: Identifier not found: missing
+    at inner (4:21)
+    at outer (5:1)`
+
+	if errObj.Inspect() != expectedInspect {
+		t.Errorf("wrong traceback. want=%q, got=%q",
+			expectedInspect, errObj.Inspect())
+	}
+}
+
+func TestErrorStackAnonymousFunction(t *testing.T) {
+	evaluated := testEval("fn() { 1 + true }()")
+
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+	}
+
+	expected := "Error: 1:8: Type mismatch: invalid operator + for types INTEGER BOOLEAN\n" +
+		"    at <anonymous> (1:1)"
+	if errObj.Inspect() != expected {
+		t.Errorf("wrong traceback. want=%q, got=%q", expected, errObj.Inspect())
+	}
+}
+
 func TestLetStatements(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -316,6 +709,88 @@ func TestStringLiteral(t *testing.T) {
 		t.Errorf("String has wrong value. got=%q", str.Value)
 	}
 }
+func TestStringInterpolation(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"plain"`, "plain"},
+		{`let name = "Monica"; "hello ${name}!"`, "hello Monica!"},
+		{`let items = [1, 2]; "${len(items)} items"`, "2 items"},
+		{`"${1 + 2}${3}"`, "33"},
+		{`"${1.5} ${true} ${[1, "b"]} ${{"k": 2}}"`, "1.5 true [1, b] {k : 2}"},
+		{`"outer ${"inner ${1 + 1}"}"`, "outer inner 2"},
+		{`let f = fn(x) { "<${x}>" }; f(f("y"))`, "<<y>>"},
+		{`"cost: \${price}"`, "cost: ${price}"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		str, ok := evaluated.(*object.String)
+		if !ok {
+			t.Errorf("%q: object is not String. got=%T (%+v)",
+				tt.input, evaluated, evaluated)
+			continue
+		}
+		if str.Value != tt.expected {
+			t.Errorf("%q: expected=%q, got=%q", tt.input, tt.expected, str.Value)
+		}
+	}
+
+	evaluated := testEval(`"a ${missing} b"`)
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("object is not Error. got=%T (%+v)", evaluated, evaluated)
+	}
+	if errObj.Message != "Identifier not found: missing" || errObj.Pos.String() != "1:6" {
+		t.Errorf("wrong error. got=%s", errObj.Inspect())
+	}
+}
+
+func TestUnicodeStrings(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`len("héllo")`, 5},
+		{`len("🐫🐫")`, 2},
+		{`len(bytes("héllo"))`, 6},
+		{`bytes("é")[1]`, 169},
+		{`"héllo"[1]`, "é"},
+		{`"a🐫b"[2]`, "b"},
+		{`let 🐫 = "camel"; 🐫`, "camel"},
+		{`let n = 0; for (c in "añb") { n += 1 }; n`, 3},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			str, ok := evaluated.(*object.String)
+			if !ok {
+				t.Errorf("%q: object is not String. got=%T (%+v)",
+					tt.input, evaluated, evaluated)
+				continue
+			}
+			if str.Value != expected {
+				t.Errorf("%q: expected=%q, got=%q", tt.input, expected, str.Value)
+			}
+		}
+	}
+
+	for _, input := range []string{`"ab"[2]`, `"ab"[-3]`} {
+		evaluated := testEval(input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok || !strings.HasPrefix(errObj.Message, "Index out of range") {
+			t.Errorf("%q: expected index error. got=%T (%+v)",
+				input, evaluated, evaluated)
+		}
+	}
+}
+
 func TestStringConcatenation(t *testing.T) {
 	input := `"Hello" + " " + "World!"`
 
@@ -392,6 +867,625 @@ func testBuiltinFunctions(t *testing.T) {
 		}
 	}
 }
+func TestLoops(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let n = 0; while (n < 5) { let n = n + 1 }; n", 5},
+		{"let n = 0; while (false) { let n = 1 }; n", 0},
+		{"let s = 0; for (x in [1, 2, 3]) { let s = s + x }; s", 6},
+		{"let s = 0; for (let i = 0; i < 4; let i = i + 1) { let s = s + i }; s", 6},
+		{"let s = 0; for (x in [1, 2, 3, 4]) { if (x == 3) { break } let s = s + x }; s", 3},
+		{"let s = 0; for (x in [1, 2, 3, 4]) { if (x == 3) { continue } let s = s + x }; s", 7},
+		{"let s = 0; for (let i = 0; i < 5; let i = i + 1) { if (i < 3) { continue } let s = s + i }; s", 7},
+		{"let s = 0; for (k in {1: true, 2: true, 3: true}) { let s = s + k }; s", 6},
+		{"let s = \"\"; for (c in \"abc\") { let s = c + s }; s", "cba"},
+		{"let f = fn() { for (x in [1, 2, 3]) { if (x == 2) { return x * 10 } } }; f()", 20},
+		{"let f = fn() { while (true) { return 1 } }; f()", 1},
+		{"while (false) { 1 }", nil},
+		{"for (x in 5) { x }", "Invalid iteration: cannot iterate over INTEGER"},
+		{"while (true) { missing }", "Identifier not found: missing"},
+		{"let s = 0; for (x in [1, 2, 3, 4]) { let y = if (x == 3) { break } else { x }; let s = s + y }; s", 3},
+		{"let s = 0; for (x in [1, 2, 3, 4]) { let s = s + if (x == 3) { continue } else { x } }; s", 7},
+		{"let add = fn(a, b) { a + b }; let s = 0; for (x in [1, 2, 3]) { let s = add(s, if (x == 2) { continue } else { x }) }; s", 4},
+		{"let a = [10, 20]; let s = 0; for (x in [0, 1, 2]) { let s = s + a[if (x == 2) { break } else { x }] }; s", 30},
+		{`let s = ""; for (x in [1, 2, 3]) { let s = s + "${if (x == 2) { continue } else { x }}" }; s`, "13"},
+		{"let f = fn() { let y = if (true) { return 1 } else { 2 }; y + 10 }; f()", 1},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case nil:
+			testNullObject(t, evaluated)
+		case string:
+			switch obj := evaluated.(type) {
+			case *object.String:
+				if obj.Value != expected {
+					t.Errorf("wrong string. expected=%q, got=%q", expected, obj.Value)
+				}
+			case *object.Error:
+				if obj.Message != expected {
+					t.Errorf("wrong error message. expected=%q, got=%q",
+						expected, obj.Message)
+				}
+			default:
+				t.Errorf("unexpected object. got=%T (%+v)", evaluated, evaluated)
+			}
+		}
+	}
+}
+
+func TestAssignment(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let x = 1; x = 5; x", 5},
+		{"let x = 1; x = x + 1", 2},
+		{"let x = 10; x += 5; x -= 3; x *= 2; x /= 4; x", 6},
+		{"let x = 3; x **= 2; x %= 5; x <<= 3; x >>= 1; x", 16},
+		{"let x = 12; x &= 10; x |= 1; x ^= 3; x", 10},
+		{"let a = 1; let b = 2; a = b = 7; a + b", 14},
+		{"let x = 1; let f = fn() { x = 10 }; f(); x", 10},
+		{"let x = 1; let f = fn() { let x = 2; x = 3; x }; f() + x", 4},
+		{"let count = 0; let inc = fn() { count += 1 }; inc(); inc(); count", 2},
+		{"let a = [1, 2, 3]; a[1] = 20; a[1]", 20},
+		{"let a = [1, 2, 3]; a[2] += 5; a[2]", 8},
+		{"let a = [1, 2]; let b = a; b[0] = 9; a[0]", 9},
+		{`let h = {"a": 1}; h["a"] = 2; h["a"]`, 2},
+		{`let h = {}; h["new"] = 3; h["new"]`, 3},
+		{`let h = {"n": 1}; h["n"] *= 10; h["n"]`, 10},
+		{"x = 1", "Cannot assign to undeclared identifier: x"},
+		{"x += 1", "Identifier not found: x"},
+		{"let a = [1]; a[1] = 2", "Index out of range: index 1, length 1"},
+		{"let a = [1, 2]; a[-1] = 5; a[1]", 5},
+		{"let a = [1]; a[-2] = 2", "Index out of range: index -2, length 1"},
+		{`let a = [1]; a["x"] = 2`, "Invalid Index: array index must be INTEGER, got STRING"},
+		{`let s = "str"; s[0] = "x"`, "Invalid Index: index assignment not supported for type STRING"},
+		{"let x = 1; x += true", "Type mismatch: invalid operator + for types INTEGER BOOLEAN"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("%q: object is not Error. got=%T (%+v)",
+					tt.input, evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q",
+					expected, errObj.Message)
+			}
+		}
+	}
+}
+
+func TestMutatingBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let a = [1]; push!(a, 2, 3); a", "[1, 2, 3]"},
+		{"let a = [1]; let b = append(a, 2); push!(b, 3); a", "[1, 2, 3]"},
+		{"let a = [1]; let b = a; append(b, 2); a", "[1, 2]"},
+		{"let a = [1]; let b = push(a, 2); [a, b]", "[[1], [1, 2]]"},
+		{"let a = [1, 2, 3]; [pop(a), a]", "[3, [1, 2]]"},
+		{"let a = [1, 3]; insert(a, 1, 2); insert(a, 3, 4); insert(a, 0, 0)", "[0, 1, 2, 3, 4]"},
+		{"let a = [1, 2, 3]; [remove(a, 0), a]", "[1, [2, 3]]"},
+		{"let a = [1, 2, 3]; [remove(a, 2), a]", "[3, [1, 2]]"},
+		{"let a = [1, 2]; clear(a); a", "[]"},
+		{`let h = {"a": 1}; clear(h); h`, "{}"},
+		{`let h = {"a": 1, "b": 2}; [delete(h, "a"), h]`, "[1, {b : 2}]"},
+		{`let h = {"a": 1}; delete(h, "z")`, "null"},
+		{`let h = {}; set(h, "k", 1); set(h, "k", 2); h`, "{k : 2}"},
+		{`let h = {}; set(h, true, 1)[true]`, "1"},
+		{"let stack = []; push!(stack, 1); push!(stack, 2); pop(stack) + pop(stack)", "3"},
+		{"pop([])", "Error: pop from empty array"},
+		{"pop(1)", "Error: argument to pop must be array, got INTEGER"},
+		{"push!([])", "Error: wrong number of arguments, expected:at least 2, got: 1"},
+		{"append(1, 2)", "Error: argument to append must be array, got INTEGER"},
+		{"insert([1], 2, 0)", "Error: Index out of range: index 2, length 1"},
+		{"insert([1], -2, 0)", "Error: Index out of range: index -2, length 1"},
+		{`insert([1], "0", 0)`, "Error: index to insert must be integer, got STRING"},
+		{"remove([1], 1)", "Error: Index out of range: index 1, length 1"},
+		{"remove([], 0)", "Error: Index out of range: index 0, length 0"},
+		{`clear("abc")`, "Error: argument to `clear` not supported, got: STRING"},
+		{"delete([1], 0)", "Error: argument to delete must be hash, got ARRAY"},
+		{"set({}, [1], 2)", "Error: Unhashable type ARRAY used as index"},
+		{"set({}, 1)", "Error: wrong number of arguments, expected:3, got: 2"},
+	}
+
+	for _, tt := range tests {
+		testInspect(t, tt.input, tt.expected)
+	}
+}
+
+func TestIndexBounds(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"[1, 2, 3][-1]", "3"},
+		{"[1, 2, 3][-3]", "1"},
+		{"let a = [1, 2]; a[len(a) - 1]", "2"},
+		{"let a = [1, 2]; a[len(a)]", "Error: Index out of range: index 2, length 2"},
+		{"[1, 2, 3][3]", "Error: Index out of range: index 3, length 3"},
+		{"[1, 2, 3][-4]", "Error: Index out of range: index -4, length 3"},
+		{"[][0]", "Error: Index out of range: index 0, length 0"},
+		{"[][-1]", "Error: Index out of range: index -1, length 0"},
+		{`"héllo"[-4]`, "é"},
+		{`"abc"[3]`, "Error: Index out of range: index 3, length 3"},
+		{`""[0]`, "Error: Index out of range: index 0, length 0"},
+		{"let a = [1, 2, 3]; a[-3] = 0; a", "[0, 2, 3]"},
+		{"let a = []; a[0] = 1", "Error: Index out of range: index 0, length 0"},
+		{"let a = [[1, 2]]; a[-1][-1]", "2"},
+	}
+
+	for _, tt := range tests {
+		testInspect(t, tt.input, tt.expected)
+	}
+}
+
+func TestBuiltinEdgeCases(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"len([])", "0"},
+		{`len("")`, "0"},
+		{"len()", "Error: wrong number of arguments, expected:1, got: 0"},
+		{"len(1)", "Error: argument to `len` not supported, got: INTEGER"},
+		{"peek([])", "Error: peek at empty array"},
+		{"peek([1])", "1"},
+		{"peek()", "Error: wrong number of arguments, expected:1, got: 0"},
+		{"pop([])", "Error: pop from empty array"},
+		{"let a = [1]; pop(a); a", "[]"},
+		{"let a = [1]; pop(a); pop(a)", "Error: pop from empty array"},
+		{"push([], 1)", "[1]"},
+		{"push([])", "Error: wrong number of arguments, expected:2, got: 1"},
+		{"let a = []; push!(a, 1); a", "[1]"},
+		{"append([], 1, 2)", "[1, 2]"},
+		{"insert([], 0, 1)", "[1]"},
+		{"insert([1, 2], -1, 0)", "[1, 0, 2]"},
+		{"insert([1], 1, 2)", "[1, 2]"},
+		{"insert([], 1, 1)", "Error: Index out of range: index 1, length 0"},
+		{"remove([1, 2, 3], -1)", "3"},
+		{"remove([1], -2)", "Error: Index out of range: index -2, length 1"},
+		{"clear([])", "[]"},
+		{"clear({})", "{}"},
+		{"delete({}, 1)", "null"},
+		{`set({}, "k", null)`, "{k : null}"},
+		{`int("")`, `Error: cannot convert "" to integer`},
+		{`float("")`, `Error: cannot convert "" to float`},
+		{`bytes("")`, "[]"},
+		{"exit(1, 2)", "Error: wrong number of arguments, expected:0 or 1, got: 2"},
+	}
+
+	for _, tt := range tests {
+		testInspect(t, tt.input, tt.expected)
+	}
+}
+
+func TestFunctionArity(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let f = fn(a, b) { a + b }; f(1)", "wrong number of arguments, expected:2, got: 1"},
+		{"let f = fn() { 1 }; f(1, 2)", "wrong number of arguments, expected:0, got: 2"},
+		{"fn(x) { x }()", "wrong number of arguments, expected:1, got: 0"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("%q: no error object returned. got=%T(%+v)",
+				tt.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expected {
+			t.Errorf("%q: wrong error message. expected=%q, got=%q",
+				tt.input, tt.expected, errObj.Message)
+		}
+	}
+}
+
+func TestCollectionBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"map([1, 2, 3], fn(x) { x * 2 })", "[2, 4, 6]"},
+		{"map([], fn(x) { x })", "[]"},
+		{`map("ab", fn(c) { c + c })`, "[aa, bb]"},
+		{"map([-1, 2], int)", "[-1, 2]"},
+		{"filter([1, 2, 3, 4], fn(x) { x % 2 == 0 })", "[2, 4]"},
+		{`filter({"a": 1, "b": 2}, fn(k) { k != "a" })`, "[b]"},
+		{"reduce([1, 2, 3], fn(acc, x) { acc + x }, 10)", "16"},
+		{"reduce([1, 2, 3], fn(acc, x) { acc * x })", "6"},
+		{"reduce([], fn(acc, x) { acc + x }, 0)", "0"},
+		{"let total = 0; each([1, 2, 3], fn(x) { total += x }); total", "6"},
+		{"sort([3, 1, 2])", "[1, 2, 3]"},
+		{"sort([2.5, 1, -3])", "[-3, 1, 2.5]"},
+		{`sort(["pear", "apple"])`, "[apple, pear]"},
+		{"sort([1, 3, 2], fn(a, b) { a > b })", "[3, 2, 1]"},
+		{"let a = [2, 1]; sort(a); a", "[2, 1]"},
+		{`sort([[2, "b"], [1, "a"], [2, "a"]], fn(a, b) { a[0] < b[0] })`, "[[1, a], [2, b], [2, a]]"},
+		{"range(4)", "[0, 1, 2, 3]"},
+		{"range(2, 5)", "[2, 3, 4]"},
+		{"range(5, 0, -2)", "[5, 3, 1]"},
+		{"range(-1)", "[]"},
+		{"range(9223372036854775806, 9223372036854775807, 5)", "[9223372036854775806]"},
+		{"zip([1, 2, 3], [4, 5])", "[[1, 4], [2, 5]]"},
+		{"zip([])", "[]"},
+		{`enumerate(["a", "b"])`, "[[0, a], [1, b]]"},
+		{`keys({"b": 1, "a": 2})`, "[a, b]"},
+		{`values({"b": 1, "a": 2})`, "[2, 1]"},
+		{"contains([1, 2.0, null], 2)", "true"},
+		{"contains([1, [2]], [2])", "false"},
+		{"contains([1, null], null)", "true"},
+		{`contains([1, "x"], true)`, "false"},
+		{`contains({"a": 1}, "a")`, "true"},
+		{`contains("camel", "me")`, "true"},
+		{"reverse([1, 2, 3])", "[3, 2, 1]"},
+		{`reverse("héllo🐫")`, "🐫olléh"},
+		{`join(["a", 1, true], ", ")`, "a, 1, true"},
+		{`join(["a", "b"])`, "ab"},
+		{`split("a,b,,c", ",")`, "[a, b, , c]"},
+		{`split("  two   words ")`, "[two, words]"},
+		{`split("hé", "")`, "[h, é]"},
+		{`join(map(split("a b"), fn(w) { w + "!" }), " ")`, "a! b!"},
+		{"map([1], fn(a, b) { a })", "Error: wrong number of arguments, expected:2, got: 1"},
+		{"map([1, 2], fn(x) { x + missing })", "Error: Identifier not found: missing"},
+		{"map(1, fn(x) { x })", "Error: Invalid iteration: cannot iterate over INTEGER"},
+		{"map([1], 2)", "Error: Invalid function call, INTEGER is not a function"},
+		{"reduce([], fn(acc, x) { acc })", "Error: reduce of empty ARRAY with no initial value"},
+		{`sort([1, "a"])`, "Error: Type mismatch: invalid operator < for types STRING INTEGER"},
+		{"range(1, 2, 0)", "Error: range step must not be zero"},
+		{"range(-9223372036854775807, 9223372036854775807)",
+			"Error: range from -9223372036854775807 to 9223372036854775807 by 1 is too long"},
+		{`range("3")`, "Error: argument to range must be integer, got STRING"},
+		{"zip([1], 2)", "Error: argument to zip must be array, got INTEGER"},
+		{"keys([1])", "Error: argument to keys must be hash, got ARRAY"},
+		{`contains("abc", 1)`, "Error: argument to contains must be string when searching a string, got INTEGER"},
+		{"reverse(1)", "Error: argument to `reverse` not supported, got: INTEGER"},
+		{`join(["a"], 1)`, "Error: separator to join must be string, got INTEGER"},
+		{"split(1)", "Error: argument to split must be string, got INTEGER"},
+	}
+
+	for _, tt := range tests {
+		testInspect(t, tt.input, tt.expected)
+	}
+}
+
+func TestBuiltinCallbackStack(t *testing.T) {
+	input := `let inc = fn(x) {
+  x + "1" * 2
+};
+map([1], inc)`
+
+	evaluated := testEval(input)
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+	}
+	if len(errObj.Stack) != 1 {
+		t.Fatalf("wrong stack depth. expected=1, got=%d", len(errObj.Stack))
+	}
+	frame := errObj.Stack[0]
+	if frame.Function != "inc" || frame.Pos.String() != "4:1" {
+		t.Errorf("wrong frame. expected=inc at 4:1, got=%s at %s",
+			frame.Function, frame.Pos)
+	}
+}
+
+func TestEvaluatorBuiltins(t *testing.T) {
+	answer := &object.Builtin{
+		Fn: func(e object.Evaluator, args ...object.Object) object.Object {
+			return &object.Integer{Value: 42}
+		},
+	}
+
+	evaluator := New()
+	evaluator.Register("answer", answer)
+	evaluator.Unregister("len")
+
+	env := object.NewEnvironment()
+	program := parser.New(lexer.New("answer()")).ParseProgram()
+	testIntegerObject(t, evaluator.Eval(program, env), 42)
+
+	program = parser.New(lexer.New("len([])")).ParseProgram()
+	errObj, ok := evaluator.Eval(program, env).(*object.Error)
+	if !ok || errObj.Message != "Identifier not found: len" {
+		t.Errorf("len was not unregistered. got=%v", errObj)
+	}
+
+	// Other evaluators keep the standard builtins.
+	testIntegerObject(t, testEval("len([1])"), 1)
+	errObj, ok = testEval("answer()").(*object.Error)
+	if !ok || errObj.Message != "Identifier not found: answer" {
+		t.Errorf("answer leaked into another evaluator. got=%v", errObj)
+	}
+
+	// Variables hide builtins.
+	program = parser.New(lexer.New("let answer = 1; answer")).ParseProgram()
+	testIntegerObject(t, evaluator.Eval(program, object.NewEnvironment()), 1)
+}
+
+func TestLimits(t *testing.T) {
+	tests := []struct {
+		limits   Limits
+		input    string
+		kind     object.ErrorKind
+		expected string
+	}{
+		{Limits{MaxSteps: 1000}, "while (true) {}", object.LIMIT_ERROR,
+			"step limit of 1000 exceeded"},
+		{Limits{MaxSteps: 1000}, "let i = 0; while (i < 10) { i += 1 }; i", "", "10"},
+		{Limits{MaxDepth: 50}, "let f = fn(n) { 1 + f(n + 1) }; f(0)", object.LIMIT_ERROR,
+			"maximum recursion depth exceeded"},
+		{Limits{MaxDepth: 50}, "let f = fn(n) { if (n > 0) { f(n - 1) } else { 0 } }; f(49)", "", "0"},
+		{Limits{MaxDuration: 20 * time.Millisecond}, "while (true) {}", object.LIMIT_ERROR,
+			"time limit of 20ms exceeded"},
+		{Limits{MaxElements: 100}, "range(1000)", object.LIMIT_ERROR,
+			"element limit of 100 exceeded"},
+		{Limits{MaxElements: 100}, "let a = []; while (true) { push!(a, 1) }", object.LIMIT_ERROR,
+			"element limit of 100 exceeded"},
+		{Limits{MaxElements: 100}, "let h = {}; let i = 0; while (true) { h[i] = i; i += 1 }", object.LIMIT_ERROR,
+			"element limit of 100 exceeded"},
+		{Limits{MaxElements: 100}, "let a = range(60); a[:]", object.LIMIT_ERROR,
+			"element limit of 100 exceeded"},
+		{Limits{MaxElements: 100}, "let h = {}; set(h, 1, 1); set(h, 1, 2); len(range(98))", "", "98"},
+		{Limits{MaxSteps: 1000}, "map([1], fn(x) { while (true) {} })", object.LIMIT_ERROR,
+			"step limit of 1000 exceeded"},
+		{Limits{MaxSteps: 1000}, "range(100000)", object.LIMIT_ERROR,
+			"step limit of 1000 exceeded"},
+		{Limits{MaxStringBytes: 1000}, `let s = "x"; while (true) { s = s + s }`, object.LIMIT_ERROR,
+			"string limit of 1000 bytes exceeded"},
+		{Limits{MaxStringBytes: 1000}, `let s = "x"; while (true) { s = "${s}${s}" }`, object.LIMIT_ERROR,
+			"string limit of 1000 bytes exceeded"},
+		{Limits{MaxStringBytes: 1000}, `let s = "x"; while (true) { s = join([s, s]) }`, object.LIMIT_ERROR,
+			"string limit of 1000 bytes exceeded"},
+		{Limits{MaxStringBytes: 1000}, `let s = "x"; while (true) { s += reverse(s) }`, object.LIMIT_ERROR,
+			"string limit of 1000 bytes exceeded"},
+		{Limits{MaxStringBytes: 1000}, `let s = "xy"; while (true) { split(s, "") }`, object.LIMIT_ERROR,
+			"string limit of 1000 bytes exceeded"},
+		{Limits{MaxStringBytes: 1000}, `len("ab" + "cd" + join([]) + join(["x"], ", "))`, "", "5"},
+		{Limits{MaxSteps: 1000}, "each(range(900), int)", object.LIMIT_ERROR,
+			"step limit of 1000 exceeded"},
+		{Limits{MaxElements: 100}, "range(1099511627776)", object.LIMIT_ERROR,
+			"element limit of 100 exceeded"},
+		{Limits{MaxDuration: 20 * time.Millisecond}, "range(9223372036854775807)", object.LIMIT_ERROR,
+			"time limit of 20ms exceeded"},
+	}
+
+	for _, tt := range tests {
+		program := parser.New(lexer.New(tt.input)).ParseProgram()
+		evaluator := New()
+		evaluator.SetLimits(tt.limits)
+
+		evaluated := evaluator.Eval(program, object.NewEnvironment())
+		errObj, ok := evaluated.(*object.Error)
+		if tt.kind == "" {
+			if ok || evaluated.Inspect() != tt.expected {
+				t.Errorf("%q: expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+			}
+			continue
+		}
+		if !ok {
+			t.Errorf("%q: no error object returned. got=%T(%+v)",
+				tt.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Kind != tt.kind || errObj.Message != tt.expected {
+			t.Errorf("%q: expected %s error %q, got %s error %q",
+				tt.input, tt.kind, tt.expected, errObj.Kind, errObj.Message)
+		}
+	}
+}
+
+func TestLimitsResetBetweenEvaluations(t *testing.T) {
+	evaluator := New()
+	evaluator.SetLimits(Limits{MaxSteps: 500, MaxElements: 10})
+	env := object.NewEnvironment()
+
+	for i := 0; i < 5; i++ {
+		program := parser.New(lexer.New("let a = range(8); let n = 0; while (n < 20) { n += 1 }")).ParseProgram()
+		if result := evaluator.Eval(program, env); isError(result) {
+			t.Fatalf("evaluation %d: unexpected error: %s", i, result.Inspect())
+		}
+	}
+}
+
+func TestDefaultRecursionDepth(t *testing.T) {
+	input := "let down = fn(n) { if (n == 0) { 0 } else { 1 + down(n - 1) } }; down(%d)"
+
+	testIntegerObject(t, testEval(fmt.Sprintf(input, DefaultMaxDepth-1)), DefaultMaxDepth-1)
+
+	evaluated := testEval(fmt.Sprintf(input, DefaultMaxDepth))
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+	}
+	if errObj.Kind != object.LIMIT_ERROR || errObj.Message != "maximum recursion depth exceeded" {
+		t.Errorf("wrong error. got %s error %q", errObj.Kind, errObj.Message)
+	}
+	if len(errObj.Stack) != DefaultMaxDepth {
+		t.Errorf("wrong stack depth. expected=%d, got=%d", DefaultMaxDepth, len(errObj.Stack))
+	}
+
+	lines := strings.Split(errObj.Inspect(), "\n")
+	if len(lines) != 22 || lines[11] != "    ... 9980 more calls" {
+		t.Errorf("long stack not shortened. got %d lines:\n%s",
+			len(lines), strings.Join(lines[:12], "\n"))
+	}
+
+	// A negative MaxDepth removes the limit.
+	program := parser.New(lexer.New(fmt.Sprintf(input, 2*DefaultMaxDepth))).ParseProgram()
+	evaluator := New()
+	evaluator.SetLimits(Limits{MaxDepth: -1})
+	testIntegerObject(t, evaluator.Eval(program, object.NewEnvironment()), 2*DefaultMaxDepth)
+}
+
+func TestTailCalls(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let sum = fn(n, acc) { if (n == 0) { acc } else { sum(n - 1, acc + n) } }; sum(100000, 0)",
+			"5000050000"},
+		{"let sum = fn(n, acc) { if (n == 0) { return acc }; return sum(n - 1, acc + n) }; sum(100000, 0)",
+			"5000050000"},
+		{`let isEven = fn(n) { if (n == 0) { true } else { isOdd(n - 1) } };
+let isOdd = fn(n) { if (n == 0) { false } else { isEven(n - 1) } };
+[isEven(100001), isOdd(100001)]`, "[false, true]"},
+		{"let count = fn(n) { while (true) { if (n == 0) { return 0 }; return count(n - 1) } }; count(50000)",
+			"0"},
+		{"let last = fn(a) { if (len(a) == 1) { a[0] } else if (len(a) == 2) { last(a[1:]) } else { last(a[1:]) } }; last(range(2000))",
+			"1999"},
+		{"let loop = fn(n) { if (n == 0) { 0 } else { let r = loop(n - 1); r } }; loop(10)", "0"},
+		{"let id = fn(x) { x }; let f = fn(x) { id(x) }; map([1, 2], f)", "[1, 2]"},
+		{"let f = fn(n) { if (n == 0) { len([1, 2]) } else { f(n - 1) } }; f(50000)", "2"},
+		{"let down = fn(n) { if (n == 0) { 0 } else { 1 + down(n - 1) } }; down(20000)",
+			"Error: maximum recursion depth exceeded"},
+		{"let f = fn(n) { g(n) }; let g = fn(a, b) { a }; f(1)",
+			"Error: wrong number of arguments, expected:2, got: 1"},
+	}
+
+	for _, tt := range tests {
+		testInspect(t, tt.input, tt.expected)
+	}
+}
+
+func TestTailCallStack(t *testing.T) {
+	input := `let fail = fn(x) { x + missing };
+let step = fn(n) { if (n == 0) { fail(n) } else { step(n - 1) } };
+let run = fn() { 1 + step(3) };
+run()`
+
+	evaluated := testEval(input)
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+	}
+
+	// The tail calls to step and fail each took over the frame of the
+	// call before them, which only leaves a count behind.
+	expected := `Error: 1:24: Identifier not found: missing
+    at fail (2:34) [via 4 tail calls]
+    at run (4:1)`
+	if errObj.Inspect() != expected {
+		t.Errorf("wrong traceback. want=%q, got=%q", expected, errObj.Inspect())
+	}
+
+	evaluated = testEval("let h = fn() { missing }; let g = fn() { h() }; g()")
+	expected = `Error: 1:16: Identifier not found: missing
+    at h (1:42) [via 1 tail call]`
+	if evaluated.Inspect() != expected {
+		t.Errorf("wrong traceback. want=%q, got=%q", expected, evaluated.Inspect())
+	}
+}
+
+func TestEvalContextCanceled(t *testing.T) {
+	program := parser.New(lexer.New("let f = fn() { while (true) {} }; f()")).ParseProgram()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	evaluated := New().EvalContext(ctx, program, object.NewEnvironment())
+	errObj, ok := evaluated.(*object.Error)
+	if !ok || errObj.Kind != object.CANCELED_ERROR ||
+		errObj.Message != "evaluation canceled: context canceled" {
+		t.Fatalf("expected canceled error. got=%T(%+v)", evaluated, evaluated)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	evaluated = New().EvalContext(ctx, program, object.NewEnvironment())
+	errObj, ok = evaluated.(*object.Error)
+	if !ok || errObj.Kind != object.CANCELED_ERROR ||
+		errObj.Message != "evaluation canceled: context deadline exceeded" {
+		t.Fatalf("expected deadline error. got=%T(%+v)", evaluated, evaluated)
+	}
+	if len(errObj.Stack) != 1 || errObj.Stack[0].Function != "f" {
+		t.Errorf("expected the stack of the canceled call. got=%v", errObj.Stack)
+	}
+}
+
+func TestSliceExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"[1, 2, 3, 4, 5][1:3]", "[2, 3]"},
+		{"[1, 2, 3, 4, 5][:2]", "[1, 2]"},
+		{"[1, 2, 3, 4, 5][3:]", "[4, 5]"},
+		{"[1, 2, 3][:]", "[1, 2, 3]"},
+		{"[1, 2, 3, 4, 5][-2:]", "[4, 5]"},
+		{"[1, 2, 3, 4, 5][:-1]", "[1, 2, 3, 4]"},
+		{"[1, 2, 3, 4, 5][-4:-2]", "[2, 3]"},
+		{"[1, 2, 3][-10:10]", "[1, 2, 3]"},
+		{"[1, 2, 3][2:1]", "[]"},
+		{"[][0:]", "[]"},
+		{"let a = [1, 2]; let b = a[:]; push!(b, 3); [a, b]", "[[1, 2], [1, 2, 3]]"},
+		{`"camel"[1:3]`, "am"},
+		{`"héllo 🐫"[1:4]`, "éll"},
+		{`"héllo 🐫"[-1:]`, "🐫"},
+		{`"abc"[5:]`, ""},
+		{"let n = null; n?[1:]", "null"},
+		{"5[1:]", "Error: Invalid Index: slice operator not supported for type INTEGER"},
+		{`[1, 2]["a":]`, "Error: Invalid Index: slice index must be INTEGER, got STRING"},
+		{"[1, 2][:missing]", "Error: Identifier not found: missing"},
+	}
+
+	for _, tt := range tests {
+		testInspect(t, tt.input, tt.expected)
+	}
+}
+
+func TestExitBuiltin(t *testing.T) {
+	tests := []struct {
+		input        string
+		expectedCode int
+	}{
+		{"exit()", 0},
+		{"exit(3)", 3},
+		{"let f = fn() { exit(7); 1 }; f(); 2", 7},
+		{"[1, exit(4), 3]", 4},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T(%+v)",
+				evaluated, evaluated)
+			continue
+		}
+
+		if errObj.Kind != object.EXIT_ERROR {
+			t.Errorf("wrong error kind. expected=%s, got=%s",
+				object.EXIT_ERROR, errObj.Kind)
+		}
+		if errObj.Code != tt.expectedCode {
+			t.Errorf("wrong exit code. expected=%d, got=%d",
+				tt.expectedCode, errObj.Code)
+		}
+	}
+}
+
 func TestArrayLiterals(t *testing.T) {
 	input := "[1, 2 * 2, 3 + 3]"
 
@@ -411,6 +1505,42 @@ func TestArrayLiterals(t *testing.T) {
 	testIntegerObject(t, result.Elements[2], 6)
 }
 
+func TestValuelessResults(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"map([1], fn(x) { let y = x })", "[null]"},
+		{"let f = fn() { let y = 1 }; contains([1], f())", "false"},
+		{"let f = fn() { let y = 1 }; [f()]", "[null]"},
+		{`let f = fn() { let y = 1 }; "${f()}"`, "null"},
+		{"let f = fn() {}; [f()]", "[null]"},
+		{"[if (true) { let y = 1 }]", "[null]"},
+	}
+
+	for _, tt := range tests {
+		testInspect(t, tt.input, tt.expected)
+	}
+}
+
+func TestInspectCycles(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let a = [1]; a[0] = a; a", "[[...]]"},
+		{`let h = {"k": 1}; h["self"] = h; h`, "{k : 1, self : {...}}"},
+		{`let a = [1]; let h = {"a": a}; a[0] = h; a`, "[{a : [...]}]"},
+		{"let b = [1]; [b, b]", "[[1], [1]]"},
+		{`let a = [1]; a[0] = a; "${a}"`, "[[...]]"},
+		{`let a = [1]; a[0] = a; join([a, 2], " ")`, "[[...]] 2"},
+	}
+
+	for _, tt := range tests {
+		testInspect(t, tt.input, tt.expected)
+	}
+}
+
 func TestArrayIndexExpressions(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -461,6 +1591,23 @@ func TestArrayIndexExpressions(t *testing.T) {
 	}
 }
 
+// testInspect evaluates input and compares how the result prints with
+// expected. An error is compared as "Error: " and its message, without
+// the position and stack.
+func testInspect(t *testing.T, input string, expected string) bool {
+
+	evaluated := testEval(input)
+	if err, ok := evaluated.(*object.Error); ok {
+		evaluated = &object.String{Value: "Error: " + err.Message}
+	}
+
+	if evaluated.Inspect() != expected {
+		t.Errorf("%q: expected=%q, got=%q", input, expected, evaluated.Inspect())
+		return false
+	}
+	return true
+}
+
 func testIntegerObject(t *testing.T, obj object.Object, expected int64) bool {
 
 	result, ok := obj.(*object.Integer)
@@ -478,6 +1625,23 @@ func testIntegerObject(t *testing.T, obj object.Object, expected int64) bool {
 	return true
 }
 
+func testFloatObject(t *testing.T, obj object.Object, expected float64) bool {
+
+	result, ok := obj.(*object.Float)
+
+	if !ok {
+		t.Errorf("obj is not Float, got: %T, (%+v)", obj, obj)
+		return false
+	}
+
+	if result.Value != expected {
+		t.Errorf("wrong value for result.Value, expected: %v, got: %v",
+			expected, result.Value)
+		return false
+	}
+	return true
+}
+
 func testBooleanObject(t *testing.T, obj object.Object, expected bool) bool {
 
 	result, ok := obj.(*object.Boolean)
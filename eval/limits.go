@@ -0,0 +1,174 @@
+package eval
+
+import (
+	"camel/ast"
+	"camel/object"
+	"context"
+	"time"
+)
+
+// checkInterval is how many steps pass between checks of the context
+// and the time limit, which are too slow to make on every step.
+const checkInterval = 1024
+
+// DefaultMaxDepth is the call depth allowed when Limits.MaxDepth is
+// zero. Each camel call takes a few kilobytes of Go stack, so this stays
+// well clear of the Go runtime's limit, which can't be recovered from.
+const DefaultMaxDepth = 10000
+
+// Limits bound the work an Evaluator does for a single call to
+// EvalContext or ApplyContext. A zero field means no limit, except for
+// MaxDepth. Going over a limit stops the program with a LIMIT_ERROR.
+type Limits struct {
+	// MaxSteps is the number of AST nodes that may be evaluated,
+	// counting the steps builtins take through Step.
+	MaxSteps int64
+	// MaxDepth is how deeply camel function calls may nest. Zero
+	// selects DefaultMaxDepth and a negative value removes the limit,
+	// leaving deep recursion to crash the process.
+	MaxDepth int
+	// MaxDuration is how long the evaluation may run.
+	MaxDuration time.Duration
+	// MaxElements is the number of array elements and hash pairs the
+	// program may create.
+	MaxElements int64
+	// MaxStringBytes is the number of bytes the strings the program
+	// builds, by concatenation, interpolation or builtins such as
+	// join, may add up to.
+	MaxStringBytes int64
+}
+
+// SetLimits sets the limits for evaluations started afterwards.
+func (e *Evaluator) SetLimits(limits Limits) {
+	e.limits = limits
+}
+
+// maxDepth returns the call depth limit, or 0 when there is none.
+func (l Limits) maxDepth() int {
+	switch {
+	case l.MaxDepth == 0:
+		return DefaultMaxDepth
+	case l.MaxDepth < 0:
+		return 0
+	}
+	return l.MaxDepth
+}
+
+// EvalContext evaluates node in env. The program stops with a
+// CANCELED_ERROR once ctx is done and with a LIMIT_ERROR when it goes
+// over one of e's limits, so hosts can run untrusted code safely.
+func (e *Evaluator) EvalContext(
+	ctx context.Context,
+	node ast.Node,
+	env *object.Environment,
+) object.Object {
+	return e.run(ctx, func() object.Object {
+		return e.eval(node, env)
+	})
+}
+
+// run calls f as a new evaluation under ctx, resetting the budgets
+// limits are checked against. An evaluation started while another is
+// in progress, such as by a builtin, shares its context and budgets.
+func (e *Evaluator) run(ctx context.Context, f func() object.Object) object.Object {
+
+	if e.ctx != nil {
+		return f()
+	}
+
+	e.ctx = ctx
+	e.steps, e.elements, e.bytes = 0, 0, 0
+	e.deadline = time.Time{}
+	if e.limits.MaxDuration > 0 {
+		e.deadline = time.Now().Add(e.limits.MaxDuration)
+	}
+	defer func() { e.ctx = nil }()
+
+	if err := e.checkContext(); err != nil {
+		return err
+	}
+	return f()
+}
+
+// step counts the evaluation of a node against the step limit, and
+// every checkInterval steps checks the context and the time limit.
+func (e *Evaluator) step() *object.Error {
+
+	e.steps++
+	if e.limits.MaxSteps > 0 && e.steps > e.limits.MaxSteps {
+		return limitError("step limit of %d exceeded", e.limits.MaxSteps)
+	}
+	if e.steps%checkInterval == 0 {
+		return e.checkContext()
+	}
+	return nil
+}
+
+// Step counts a step taken by a builtin like the evaluation of a node,
+// so that long loops in builtins also stop for the limits and ctx.
+func (e *Evaluator) Step() *object.Error {
+	if e.ctx == nil {
+		return nil
+	}
+	return e.step()
+}
+
+func (e *Evaluator) checkContext() *object.Error {
+
+	if err := e.ctx.Err(); err != nil {
+		return &object.Error{
+			Kind:    object.CANCELED_ERROR,
+			Message: "evaluation canceled: " + err.Error(),
+		}
+	}
+	if !e.deadline.IsZero() && time.Now().After(e.deadline) {
+		return limitError("time limit of %s exceeded", e.limits.MaxDuration)
+	}
+	return nil
+}
+
+// allocate counts n new array elements or hash pairs against the
+// element limit.
+func (e *Evaluator) allocate(n int) *object.Error {
+
+	e.elements += int64(n)
+	if e.limits.MaxElements > 0 && e.elements > e.limits.MaxElements {
+		return limitError("element limit of %d exceeded", e.limits.MaxElements)
+	}
+	return nil
+}
+
+// allocate counts n new elements against the limits of the evaluator
+// running a builtin.
+func allocate(e object.Evaluator, n int) *object.Error {
+	if e, ok := e.(*Evaluator); ok {
+		return e.allocate(n)
+	}
+	return nil
+}
+
+// allocateString counts a new string of n bytes against the string
+// limit.
+func (e *Evaluator) allocateString(n int) *object.Error {
+
+	e.bytes += int64(n)
+	if e.limits.MaxStringBytes > 0 && e.bytes > e.limits.MaxStringBytes {
+		return limitError("string limit of %d bytes exceeded", e.limits.MaxStringBytes)
+	}
+	return nil
+}
+
+// allocateString counts a new string of n bytes against the limits of
+// the evaluator running a builtin.
+func allocateString(e object.Evaluator, n int) *object.Error {
+	if e, ok := e.(*Evaluator); ok {
+		return e.allocateString(n)
+	}
+	return nil
+}
+
+func limitError(format string, a ...interface{}) *object.Error {
+	err := newError(format, a...)
+	err.Kind = object.LIMIT_ERROR
+	return err
+}
@@ -2,36 +2,102 @@ package lexer
 
 import (
 	"camel/token"
+	"unicode"
+	"unicode/utf8"
 )
 
 type Lexer struct {
 	input        string
+	filename     string
 	position     int
 	readPosition int
-	char         byte
+	char         rune // the character at position, 0 at the end
+
+	line   int
+	column int
+
+	// interpolations holds, for each "${" being lexed, the number of
+	// braces opened inside it and not yet closed, so the "}" ending the
+	// embedded expression can be told apart from one closing a block
+	// or a hash.
+	interpolations []int
+
+	// comments collects the comments skipped so far, in source order.
+	comments []token.Token
+
+	dialect *token.Dialect
 }
 
 func New(input string) *Lexer {
-	lex := &Lexer{input: input}
+	return NewFile("", input)
+}
+
+// NewFile returns a lexer whose token positions refer to filename.
+func NewFile(filename, input string) *Lexer {
+	lex := &Lexer{input: input, filename: filename, line: 1, dialect: token.English}
 	lex.readChar()
+	lex.skipShebang()
 	return lex
 }
 
+// SetDialect sets the words the lexer recognizes as keywords. It must
+// be called before the first token is read; nil selects English.
+func (lex *Lexer) SetDialect(dialect *token.Dialect) {
+	if dialect == nil {
+		dialect = token.English
+	}
+	lex.dialect = dialect
+}
+
+// skipShebang ignores a leading "#!" interpreter line so scripts can be
+// made executable.
+func (lex *Lexer) skipShebang() {
+
+	if lex.char != '#' || lex.peekChar() != '!' {
+		return
+	}
+	for lex.char != '\n' && lex.char != 0 {
+		lex.readChar()
+	}
+}
+
 func (lexer *Lexer) readChar() {
 
+	if lexer.readPosition > len(lexer.input) {
+		return
+	}
+	if lexer.char == '\n' {
+		lexer.line += 1
+		lexer.column = 0
+	}
+	width := 1
 	if lexer.readPosition >= len(lexer.input) {
 		lexer.char = 0
 	} else {
-		lexer.char = lexer.input[lexer.readPosition]
+		lexer.char, width = utf8.DecodeRuneInString(lexer.input[lexer.readPosition:])
 	}
 	lexer.position = lexer.readPosition
-	lexer.readPosition += 1
+	lexer.readPosition += width
+	lexer.column += 1
+}
+
+// pos returns the position of the current character.
+func (lex *Lexer) pos() token.Position {
+	return token.Position{
+		Filename: lex.filename,
+		Offset:   lex.position,
+		Line:     lex.line,
+		Column:   lex.column,
+	}
 }
 
 func (lex *Lexer) NextToken() token.Token {
 
 	var tok token.Token
-	lex.eatSpace()
+	if tok, ok := lex.eatSpace(); !ok {
+		return tok
+	}
+	start := lex.pos()
 
 	switch lex.char {
 
@@ -43,17 +109,67 @@ func (lex *Lexer) NextToken() token.Token {
 			tok = newToken(token.ASSIGN, lex.char)
 		}
 	case '+':
-		tok = newToken(token.PLUS, lex.char)
+		tok = lex.withAssign(token.PLUS, token.PLUS_ASSIGN)
 	case '-':
-		tok = newToken(token.MINUS, lex.char)
+		tok = lex.withAssign(token.MINUS, token.MINUS_ASSIGN)
 	case '*':
-		tok = newToken(token.ASTERISK, lex.char)
+		if lex.peekChar() == '*' {
+			lex.readChar()
+			tok = lex.withAssign(token.POWER, token.POWER_ASSIGN)
+		} else {
+			tok = lex.withAssign(token.ASTERISK, token.ASTERISK_ASSIGN)
+		}
 	case '/':
-		tok = newToken(token.SLASH, lex.char)
+		tok = lex.withAssign(token.SLASH, token.SLASH_ASSIGN)
+	case '%':
+		tok = lex.withAssign(token.PERCENT, token.PERCENT_ASSIGN)
 	case '<':
-		tok = newToken(token.LT, lex.char)
+		if lex.peekChar() == '<' {
+			lex.readChar()
+			tok = lex.withAssign(token.SHIFT_LEFT, token.SHIFT_LEFT_ASSIGN)
+		} else {
+			tok = lex.withAssign(token.LT, token.LT_EQ)
+		}
 	case '>':
-		tok = newToken(token.GT, lex.char)
+		if lex.peekChar() == '>' {
+			lex.readChar()
+			tok = lex.withAssign(token.SHIFT_RIGHT, token.SHIFT_RIGHT_ASSIGN)
+		} else {
+			tok = lex.withAssign(token.GT, token.GT_EQ)
+		}
+	case '&':
+		if lex.peekChar() == '&' {
+			lex.readChar()
+			tok = token.Token{Type: token.AND, Literal: "&&"}
+		} else {
+			tok = lex.withAssign(token.BIT_AND, token.BIT_AND_ASSIGN)
+		}
+	case '|':
+		if lex.peekChar() == '|' {
+			lex.readChar()
+			tok = token.Token{Type: token.OR, Literal: "||"}
+		} else {
+			tok = lex.withAssign(token.BIT_OR, token.BIT_OR_ASSIGN)
+		}
+	case '^':
+		tok = lex.withAssign(token.BIT_XOR, token.BIT_XOR_ASSIGN)
+	case '~':
+		tok = newToken(token.BIT_NOT, lex.char)
+	case '?':
+		switch lex.peekChar() {
+		case '?':
+			lex.readChar()
+			tok = token.Token{Type: token.NULLISH, Literal: "??"}
+		case '[':
+			lex.readChar()
+			tok = token.Token{Type: token.OPTIONAL_INDEX, Literal: "?["}
+		case '(':
+			lex.readChar()
+			tok = token.Token{Type: token.OPTIONAL_CALL, Literal: "?("}
+		default:
+			tok.Literal = "unexpected character `?`"
+			tok.Type = token.ILLEGAL
+		}
 	case ';':
 		tok = newToken(token.SEMICOLON, lex.char)
 	case ':':
@@ -65,8 +181,19 @@ func (lex *Lexer) NextToken() token.Token {
 	case ')':
 		tok = newToken(token.RPAREN, lex.char)
 	case '{':
+		if n := len(lex.interpolations); n > 0 {
+			lex.interpolations[n-1]++
+		}
 		tok = newToken(token.LBRACE, lex.char)
 	case '}':
+		if n := len(lex.interpolations); n > 0 {
+			if lex.interpolations[n-1] == 0 {
+				lex.interpolations = lex.interpolations[:n-1]
+				tok.Literal, tok.Type = lex.readString(token.INTERP_END, token.INTERP_MID)
+				break
+			}
+			lex.interpolations[n-1]--
+		}
 		tok = newToken(token.RBRACE, lex.char)
 	case '[':
 		tok = newToken(token.LBRACKET, lex.char)
@@ -80,92 +207,240 @@ func (lex *Lexer) NextToken() token.Token {
 			tok = newToken(token.BANG, lex.char)
 		}
 	case '"':
-		tok.Type = token.STRING
-		tok.Literal = lex.readString()
+		if lex.peekChar() == '"' && lex.charAt(lex.readPosition+1) == '"' {
+			tok.Literal, tok.Type = lex.readTextBlock()
+		} else {
+			tok.Literal, tok.Type = lex.readString(token.STRING, token.INTERP_START)
+		}
+	case '`':
+		tok.Literal, tok.Type = lex.readRawString()
 	case 0:
 		tok.Literal = ""
 		tok.Type = token.EOF
 	default:
 		if isLetter(lex.char) {
 			tok.Literal = lex.readIdentifier()
-			tok.Type = token.LookUpIdent(tok.Literal)
+			tok.Type = lex.dialect.LookUpIdent(tok.Literal)
+			tok.Pos, tok.End = start, lex.pos()
 
 			return tok
 
 		} else if isDigit(lex.char) {
-			tok.Literal = lex.readNumber()
-			tok.Type = token.INT
+			tok.Literal, tok.Type = lex.readNumber()
+			tok.Pos, tok.End = start, lex.pos()
 
 			return tok
 
+		} else if lex.char == utf8.RuneError {
+
+			tok.Literal = "invalid UTF-8 encoding"
+			tok.Type = token.ILLEGAL
 		} else {
 
-			tok.Literal = ""
+			tok.Literal = "unexpected character `" + string(lex.char) + "`"
 			tok.Type = token.ILLEGAL
 		}
 	}
 
 	lex.readChar()
+	tok.Pos, tok.End = start, lex.pos()
 	return tok
 }
 
-func newToken(tokenType token.TokenType, ch byte) token.Token {
+func newToken(tokenType token.TokenType, ch rune) token.Token {
 	return token.Token{Type: tokenType, Literal: string(ch)}
 }
 
+// withAssign returns the "op=" form of an operator, such as a compound
+// assignment or "<=", when the current character is followed by '=',
+// and the plain operator otherwise. The current character must be the
+// last one of the plain operator.
+func (lex *Lexer) withAssign(op, assign token.TokenType) token.Token {
+
+	if lex.peekChar() == '=' {
+		lex.readChar()
+		return token.Token{Type: assign, Literal: string(assign)}
+	}
+	return token.Token{Type: op, Literal: string(op)}
+}
+
+// bangNames are the identifiers that may end in '!': the builtins that
+// change their argument in place. Anywhere else '!' is the operator.
+var bangNames = map[string]bool{
+	"push": true,
+}
+
 func (lex *Lexer) readIdentifier() string {
 
 	pos := lex.position
-	for isLetter(lex.char) {
+	for isLetter(lex.char) || isIdentifierPart(lex.char) {
+		lex.readChar()
+	}
+	// A trailing '!' marks a builtin that mutates its argument, as in
+	// push!, but push!=y is still push != y.
+	if lex.char == '!' && lex.peekChar() != '=' && bangNames[lex.input[pos:lex.position]] {
 		lex.readChar()
 	}
 	return lex.input[pos:lex.position]
 
 }
-func (lex *Lexer) readString() string {
 
-	pos := lex.position + 1
-	for {
+// readNumber reads an integer, or a float when the digits are followed
+// by a fraction such as ".5", an exponent such as "e-3", or both. The
+// '.' and the 'e' are only taken when a digit follows them.
+func (lex *Lexer) readNumber() (string, token.TokenType) {
+	pos := lex.position
+	tokenType := token.TokenType(token.INT)
+
+	lex.readDigits()
+	if lex.char == '.' && isDigit(lex.peekChar()) {
+		tokenType = token.FLOAT
 		lex.readChar()
-		if lex.char == '"' || lex.char == 0 {
-			break
+		lex.readDigits()
+	}
+	if lex.char == 'e' || lex.char == 'E' {
+		next := lex.readPosition
+		if sign := lex.charAt(next); sign == '+' || sign == '-' {
+			next++
+		}
+		if isDigit(rune(lex.charAt(next))) {
+			tokenType = token.FLOAT
+			for lex.readPosition < next {
+				lex.readChar()
+			}
+			lex.readChar()
+			lex.readDigits()
 		}
 	}
-
-	return lex.input[pos:lex.position]
+	return lex.input[pos:lex.position], tokenType
 }
 
-func (lex *Lexer) readNumber() string {
-	pos := lex.position
+func (lex *Lexer) readDigits() {
 	for isDigit(lex.char) {
 		lex.readChar()
 	}
-	return lex.input[pos:lex.position]
 }
-func isDigit(ch byte) bool {
+func isDigit(ch rune) bool {
 	return '0' <= ch && ch <= '9'
 }
-func isLetter(ch byte) bool {
+
+// isLetter reports whether ch can start an identifier: a letter of
+// any script, an underscore or an emoji.
+func isLetter(ch rune) bool {
 
 	return 'A' <= ch && ch <= 'Z' ||
 		'a' <= ch && ch <= 'z' ||
-		ch == '_'
+		ch == '_' ||
+		ch > unicode.MaxASCII && (unicode.IsLetter(ch) || isEmoji(ch))
+}
+
+// isIdentifierPart reports whether ch can continue an identifier after
+// its first character: digits, combining marks such as accents, and
+// the joiner that glues emoji sequences together.
+func isIdentifierPart(ch rune) bool {
+
+	return isDigit(ch) ||
+		ch > unicode.MaxASCII && (unicode.IsDigit(ch) ||
+			unicode.In(ch, unicode.Mn, unicode.Mc) ||
+			ch == '\u200d')
+}
+
+// isEmoji approximates emoji as the non-ASCII symbol characters, which
+// include the skin tone modifiers. The replacement character stands
+// for invalid UTF-8 and is left out.
+func isEmoji(ch rune) bool {
+	return ch != utf8.RuneError && unicode.In(ch, unicode.So, unicode.Sk)
+}
+
+// eatSpace skips white space and comments, recording each comment. A
+// block comment that is never closed is returned as an ILLEGAL token
+// with ok set to false.
+func (lex *Lexer) eatSpace() (tok token.Token, ok bool) {
+
+	for {
+		switch {
+		case lex.char == ' ' ||
+			lex.char == '\n' ||
+			lex.char == '\t' ||
+			lex.char == '\r':
+			lex.readChar()
+		case lex.char == '/' && lex.peekChar() == '/':
+			lex.readComment()
+		case lex.char == '/' && lex.peekChar() == '*':
+			if !lex.readComment() {
+				start := lex.comments[len(lex.comments)-1].Pos
+				lex.comments = lex.comments[:len(lex.comments)-1]
+				return token.Token{
+					Type:    token.ILLEGAL,
+					Literal: "unterminated block comment",
+					Pos:     start,
+					End:     lex.pos(),
+				}, false
+			}
+		default:
+			return tok, true
+		}
+	}
 }
 
-func (lex *Lexer) eatSpace() {
+// readComment reads a "//" comment up to the end of the line, or a
+// "/* */" comment up to its closing delimiter, and records it. It
+// reports false when a block comment runs into the end of the input.
+func (lex *Lexer) readComment() bool {
 
-	for lex.char == ' ' ||
-		lex.char == '\n' ||
-		lex.char == '\t' ||
-		lex.char == '\r' {
+	start := lex.pos()
+	block := lex.peekChar() == '*'
+	lex.readChar()
+	lex.readChar()
+
+	closed := true
+	for {
+		if block && lex.char == '*' && lex.peekChar() == '/' {
+			lex.readChar()
+			lex.readChar()
+			break
+		}
+		if !block && (lex.char == '\n' || lex.char == 0) {
+			break
+		}
+		if lex.char == 0 {
+			closed = false
+			break
+		}
 		lex.readChar()
 	}
+
+	lex.comments = append(lex.comments, token.Token{
+		Type:    token.COMMENT,
+		Literal: lex.input[start.Offset:lex.position],
+		Pos:     start,
+		End:     lex.pos(),
+	})
+	return closed
+}
+
+// Comments returns the comments the lexer has skipped so far, in the
+// order they appear in the source. They are not part of the token
+// stream, but tools such as a formatter can attach them back to the
+// syntax tree by position.
+func (lex *Lexer) Comments() []token.Token {
+	return lex.comments
 }
 
-func (lex *Lexer) peekChar() byte {
+func (lex *Lexer) peekChar() rune {
 
 	if lex.readPosition >= len(lex.input) {
 		return 0
 	}
-	return lex.input[lex.readPosition]
+	ch, _ := utf8.DecodeRuneInString(lex.input[lex.readPosition:])
+	return ch
+}
+
+// charAt returns the byte at offset i of the input, or 0 past its end.
+func (lex *Lexer) charAt(i int) byte {
+
+	if i >= len(lex.input) {
+		return 0
+	}
+	return lex.input[i]
 }
@@ -19,6 +19,15 @@ let result = add(five , ten);
 "foobar" 
 "foo bar" 
 [2,3] 
+while for in break continue
++= -= *= /=
+<= >= && ||
+% ** & | ^ ~ << >>
+%= **= &= |= ^= <<= >>=
+3.14 1e3 2.5E-4 7e+2 1.x 4e
+null ?? ?[ ?( ?
+push!(a) a!=b !c
+foo!x push!=y
 `
 
 	tests := []struct {
@@ -81,6 +90,63 @@ let result = add(five , ten);
 		{token.COMMA, ","},
 		{token.INT, "3"},
 		{token.RBRACKET, "]"},
+		{token.WHILE, "while"},
+		{token.FOR, "for"},
+		{token.IN, "in"},
+		{token.BREAK, "break"},
+		{token.CONTINUE, "continue"},
+		{token.PLUS_ASSIGN, "+="},
+		{token.MINUS_ASSIGN, "-="},
+		{token.ASTERISK_ASSIGN, "*="},
+		{token.SLASH_ASSIGN, "/="},
+		{token.LT_EQ, "<="},
+		{token.GT_EQ, ">="},
+		{token.AND, "&&"},
+		{token.OR, "||"},
+		{token.PERCENT, "%"},
+		{token.POWER, "**"},
+		{token.BIT_AND, "&"},
+		{token.BIT_OR, "|"},
+		{token.BIT_XOR, "^"},
+		{token.BIT_NOT, "~"},
+		{token.SHIFT_LEFT, "<<"},
+		{token.SHIFT_RIGHT, ">>"},
+		{token.PERCENT_ASSIGN, "%="},
+		{token.POWER_ASSIGN, "**="},
+		{token.BIT_AND_ASSIGN, "&="},
+		{token.BIT_OR_ASSIGN, "|="},
+		{token.BIT_XOR_ASSIGN, "^="},
+		{token.SHIFT_LEFT_ASSIGN, "<<="},
+		{token.SHIFT_RIGHT_ASSIGN, ">>="},
+		{token.FLOAT, "3.14"},
+		{token.FLOAT, "1e3"},
+		{token.FLOAT, "2.5E-4"},
+		{token.FLOAT, "7e+2"},
+		{token.INT, "1"},
+		{token.ILLEGAL, "unexpected character `.`"},
+		{token.IDENT, "x"},
+		{token.INT, "4"},
+		{token.IDENT, "e"},
+		{token.NULL, "null"},
+		{token.NULLISH, "??"},
+		{token.OPTIONAL_INDEX, "?["},
+		{token.OPTIONAL_CALL, "?("},
+		{token.ILLEGAL, "unexpected character `?`"},
+		{token.IDENT, "push!"},
+		{token.LPAREN, "("},
+		{token.IDENT, "a"},
+		{token.RPAREN, ")"},
+		{token.IDENT, "a"},
+		{token.NOT_EQ, "!="},
+		{token.IDENT, "b"},
+		{token.BANG, "!"},
+		{token.IDENT, "c"},
+		{token.IDENT, "foo"},
+		{token.BANG, "!"},
+		{token.IDENT, "x"},
+		{token.IDENT, "push"},
+		{token.NOT_EQ, "!="},
+		{token.IDENT, "y"},
 		{token.EOF, ""},
 	}
 
@@ -102,3 +168,346 @@ let result = add(five , ten);
 
 	}
 }
+
+func TestTokenPositions(t *testing.T) {
+
+	input := `let x = 10;
+  add(x, "hi")`
+
+	tests := []struct {
+		expectedLiteral string
+		expectedPos     token.Position
+		expectedEnd     token.Position
+	}{
+		{"let", token.Position{Filename: "t.cml", Offset: 0, Line: 1, Column: 1},
+			token.Position{Filename: "t.cml", Offset: 3, Line: 1, Column: 4}},
+		{"x", token.Position{Filename: "t.cml", Offset: 4, Line: 1, Column: 5},
+			token.Position{Filename: "t.cml", Offset: 5, Line: 1, Column: 6}},
+		{"=", token.Position{Filename: "t.cml", Offset: 6, Line: 1, Column: 7},
+			token.Position{Filename: "t.cml", Offset: 7, Line: 1, Column: 8}},
+		{"10", token.Position{Filename: "t.cml", Offset: 8, Line: 1, Column: 9},
+			token.Position{Filename: "t.cml", Offset: 10, Line: 1, Column: 11}},
+		{";", token.Position{Filename: "t.cml", Offset: 10, Line: 1, Column: 11},
+			token.Position{Filename: "t.cml", Offset: 11, Line: 1, Column: 12}},
+		{"add", token.Position{Filename: "t.cml", Offset: 14, Line: 2, Column: 3},
+			token.Position{Filename: "t.cml", Offset: 17, Line: 2, Column: 6}},
+		{"(", token.Position{Filename: "t.cml", Offset: 17, Line: 2, Column: 6},
+			token.Position{Filename: "t.cml", Offset: 18, Line: 2, Column: 7}},
+		{"x", token.Position{Filename: "t.cml", Offset: 18, Line: 2, Column: 7},
+			token.Position{Filename: "t.cml", Offset: 19, Line: 2, Column: 8}},
+		{",", token.Position{Filename: "t.cml", Offset: 19, Line: 2, Column: 8},
+			token.Position{Filename: "t.cml", Offset: 20, Line: 2, Column: 9}},
+		{"hi", token.Position{Filename: "t.cml", Offset: 21, Line: 2, Column: 10},
+			token.Position{Filename: "t.cml", Offset: 25, Line: 2, Column: 14}},
+		{")", token.Position{Filename: "t.cml", Offset: 25, Line: 2, Column: 14},
+			token.Position{Filename: "t.cml", Offset: 26, Line: 2, Column: 15}},
+		{"", token.Position{Filename: "t.cml", Offset: 26, Line: 2, Column: 15},
+			token.Position{Filename: "t.cml", Offset: 26, Line: 2, Column: 15}},
+	}
+
+	lex := NewFile("t.cml", input)
+
+	for idx, testCase := range tests {
+
+		tok := lex.NextToken()
+
+		if testCase.expectedLiteral != tok.Literal {
+			t.Fatalf("tests[%d] - wrong token literal expected [%q] : got [%q]",
+				idx, testCase.expectedLiteral, tok.Literal)
+		}
+
+		if testCase.expectedPos != tok.Pos {
+			t.Errorf("tests[%d] - wrong start position expected [%+v] : got [%+v]",
+				idx, testCase.expectedPos, tok.Pos)
+		}
+
+		if testCase.expectedEnd != tok.End {
+			t.Errorf("tests[%d] - wrong end position expected [%+v] : got [%+v]",
+				idx, testCase.expectedEnd, tok.End)
+		}
+	}
+}
+
+func TestStringLiterals(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{`"plain"`, token.STRING, "plain"},
+		{`"say \"hi\""`, token.STRING, `say "hi"`},
+		{`"a\tb\nc\r\\"`, token.STRING, "a\tb\nc\r\\"},
+		{`"\u{41}\u{1F42B}"`, token.STRING, "A\U0001F42B"},
+		{"\"two\nlines\"", token.STRING, "two\nlines"},
+		{"`raw \\n ${x} \"q\"`", token.STRING, `raw \n ${x} "q"`},
+		{"`multi\nline`", token.STRING, "multi\nline"},
+		{"\"\"\"\n    first\n      second\n\n    third\\t\n    \"\"\"", token.STRING,
+			"first\n  second\n\nthird\t"},
+		{`"""one "quoted" line"""`, token.STRING, `one "quoted" line`},
+		{`""`, token.STRING, ""},
+		{`"open`, token.ILLEGAL, "unterminated string literal"},
+		{`"open\"`, token.ILLEGAL, "unterminated string literal"},
+		{"`open", token.ILLEGAL, "unterminated raw string literal"},
+		{`"""open""`, token.ILLEGAL, "unterminated multi-line string literal"},
+		{"\"\"\"\\\n\"\"\"", token.ILLEGAL, "unterminated escape sequence"},
+		{`"\q"`, token.ILLEGAL, "unknown escape sequence `\\q`"},
+		{`"\u41"`, token.ILLEGAL, "invalid unicode escape, expected `\\u{hex}`"},
+		{`"\u{D800}"`, token.ILLEGAL, "invalid unicode escape, expected `\\u{hex}`"},
+	}
+
+	for idx, tt := range tests {
+		tok := New(tt.input).NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Errorf("tests[%d] - wrong token type expected [%q] : got [%q]",
+				idx, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Errorf("tests[%d] - wrong token literal expected [%q] : got [%q]",
+				idx, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestStringFollowedByToken(t *testing.T) {
+
+	lex := New("\"\"\"a\"\"\" + `b`;")
+
+	for _, expected := range []token.TokenType{
+		token.STRING, token.PLUS, token.STRING, token.SEMICOLON, token.EOF,
+	} {
+		if tok := lex.NextToken(); tok.Type != expected {
+			t.Fatalf("wrong token type expected [%q] : got [%q] %q",
+				expected, tok.Type, tok.Literal)
+		}
+	}
+}
+
+func TestStringInterpolation(t *testing.T) {
+
+	input := `"a ${x} b ${ {"k": f("${y}")}["k"] } c" "$x \${z}" {}`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.INTERP_START, "a "},
+		{token.IDENT, "x"},
+		{token.INTERP_MID, " b "},
+		{token.LBRACE, "{"},
+		{token.STRING, "k"},
+		{token.COLON, ":"},
+		{token.IDENT, "f"},
+		{token.LPAREN, "("},
+		{token.INTERP_START, ""},
+		{token.IDENT, "y"},
+		{token.INTERP_END, ""},
+		{token.RPAREN, ")"},
+		{token.RBRACE, "}"},
+		{token.LBRACKET, "["},
+		{token.STRING, "k"},
+		{token.RBRACKET, "]"},
+		{token.INTERP_END, " c"},
+		{token.STRING, "$x ${z}"},
+		{token.LBRACE, "{"},
+		{token.RBRACE, "}"},
+		{token.EOF, ""},
+	}
+
+	lex := New(input)
+
+	for idx, testCase := range tests {
+
+		tok := lex.NextToken()
+
+		if testCase.expectedType != tok.Type {
+			t.Fatalf("tests[%d] - wrong token type expected [%q] : got [%q]",
+				idx, testCase.expectedType, tok.Type)
+		}
+
+		if testCase.expectedLiteral != tok.Literal {
+			t.Fatalf("tests[%d] - wrong token literal expected [%q] : got [%q]",
+				idx, testCase.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestUnicode(t *testing.T) {
+
+	input := "let ñandú = \"héllo 🐫\"; 🐫 + x1 - café_2 * 👍🏽 € \xff"
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+		expectedColumn  int
+	}{
+		{token.LET, "let", 1},
+		{token.IDENT, "ñandú", 5},
+		{token.ASSIGN, "=", 11},
+		{token.STRING, "héllo 🐫", 13},
+		{token.SEMICOLON, ";", 22},
+		{token.IDENT, "🐫", 24},
+		{token.PLUS, "+", 26},
+		{token.IDENT, "x1", 28},
+		{token.MINUS, "-", 31},
+		{token.IDENT, "café_2", 33},
+		{token.ASTERISK, "*", 40},
+		{token.IDENT, "👍🏽", 42},
+		{token.ILLEGAL, "unexpected character `€`", 45},
+		{token.ILLEGAL, "invalid UTF-8 encoding", 47},
+		{token.EOF, "", 48},
+	}
+
+	lex := New(input)
+
+	for idx, testCase := range tests {
+
+		tok := lex.NextToken()
+
+		if testCase.expectedType != tok.Type {
+			t.Fatalf("tests[%d] - wrong token type expected [%q] : got [%q]",
+				idx, testCase.expectedType, tok.Type)
+		}
+
+		if testCase.expectedLiteral != tok.Literal {
+			t.Fatalf("tests[%d] - wrong token literal expected [%q] : got [%q]",
+				idx, testCase.expectedLiteral, tok.Literal)
+		}
+
+		if testCase.expectedColumn != tok.Pos.Column {
+			t.Errorf("tests[%d] - wrong column expected [%d] : got [%d]",
+				idx, testCase.expectedColumn, tok.Pos.Column)
+		}
+	}
+}
+
+func TestComments(t *testing.T) {
+
+	input := `// leading
+let x = 10 /* ten */ / 2; // half
+/* multi
+   line */ x //
+/* open`
+
+	expectedTokens := []token.TokenType{
+		token.LET, token.IDENT, token.ASSIGN, token.INT, token.SLASH,
+		token.INT, token.SEMICOLON, token.IDENT, token.ILLEGAL,
+	}
+
+	lex := New(input)
+
+	for idx, expected := range expectedTokens {
+		tok := lex.NextToken()
+		if tok.Type != expected {
+			t.Fatalf("tests[%d] - wrong token type expected [%q] : got [%q] %q",
+				idx, expected, tok.Type, tok.Literal)
+		}
+	}
+
+	tok := lex.NextToken()
+	if tok.Type != token.EOF {
+		t.Fatalf("expected EOF after unterminated comment, got [%q]", tok.Type)
+	}
+
+	expectedComments := []struct {
+		literal string
+		pos     string
+		end     string
+	}{
+		{"// leading", "1:1", "1:11"},
+		{"/* ten */", "2:12", "2:21"},
+		{"// half", "2:27", "2:34"},
+		{"/* multi\n   line */", "3:1", "4:11"},
+		{"//", "4:14", "4:16"},
+	}
+
+	comments := lex.Comments()
+	if len(comments) != len(expectedComments) {
+		t.Fatalf("wrong number of comments. want=%d, got=%d",
+			len(expectedComments), len(comments))
+	}
+	for i, expected := range expectedComments {
+		comment := comments[i]
+		if comment.Type != token.COMMENT || comment.Literal != expected.literal {
+			t.Errorf("comments[%d] - expected [%q] : got [%q] %q",
+				i, expected.literal, comment.Type, comment.Literal)
+		}
+		if comment.Pos.String() != expected.pos || comment.End.String() != expected.end {
+			t.Errorf("comments[%d] - wrong span expected %s-%s : got %s-%s",
+				i, expected.pos, expected.end, comment.Pos, comment.End)
+		}
+	}
+}
+
+func TestDialects(t *testing.T) {
+
+	french, err := token.ParseDialect([]byte(
+		`{"name": "french", "keywords": {"soit": "let", "fonction": "fn", "renvoie": "return"}}`))
+	if err != nil {
+		t.Fatalf("ParseDialect failed: %s", err)
+	}
+
+	tests := []struct {
+		dialect  *token.Dialect
+		input    string
+		expected []token.TokenType
+	}{
+		{nil, "let fn return beza foo",
+			[]token.TokenType{token.LET, token.FUNCTION, token.RETURN, token.IDENT, token.IDENT}},
+		{token.Persian, "beza foo bar bede let fn if else",
+			[]token.TokenType{token.LET, token.FUNCTION, token.FUNCTION, token.RETURN,
+				token.IDENT, token.IDENT, token.IF, token.ELSE}},
+		{french, "soit fonction renvoie let while",
+			[]token.TokenType{token.LET, token.FUNCTION, token.RETURN, token.IDENT, token.WHILE}},
+	}
+
+	for _, tt := range tests {
+		lex := New(tt.input)
+		lex.SetDialect(tt.dialect)
+
+		for idx, expected := range tt.expected {
+			tok := lex.NextToken()
+			if tok.Type != expected {
+				t.Errorf("%q[%d] - wrong token type expected [%q] : got [%q]",
+					tt.input, idx, expected, tok.Type)
+			}
+		}
+	}
+}
+
+func TestInvalidDialects(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{`{"keywords": {"soit": "lett"}}`, `dialect custom: "lett" is not a keyword`},
+		{`{"name": "x", "keywords": {"": "let"}}`, `dialect x: empty word for "let"`},
+		{`{"name": "x", "keywords": {"if": "let"}}`, `dialect x: "if" is already the keyword "if"`},
+	}
+
+	for _, tt := range tests {
+		_, err := token.ParseDialect([]byte(tt.input))
+		if err == nil {
+			t.Errorf("%s: expected an error", tt.input)
+			continue
+		}
+		if err.Error() != tt.expectedError {
+			t.Errorf("%s: wrong error. want=%q, got=%q", tt.input, tt.expectedError, err)
+		}
+	}
+}
+
+func TestShebangLine(t *testing.T) {
+
+	input := "#!/usr/bin/env camel\nlet x = 1;"
+
+	lex := New(input)
+
+	tok := lex.NextToken()
+	if tok.Type != token.LET {
+		t.Fatalf("shebang line not skipped, got [%q] %q", tok.Type, tok.Literal)
+	}
+	if tok.Pos.Line != 2 || tok.Pos.Column != 1 {
+		t.Errorf("wrong position after shebang, got %s", tok.Pos)
+	}
+}
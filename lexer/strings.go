@@ -0,0 +1,205 @@
+package lexer
+
+import (
+	"camel/token"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// readString reads the text of a double-quoted string, after its
+// opening quote or after the "}" of an interpolation, and returns it
+// with escape sequences resolved. The text ends either at the closing
+// quote, giving a token of type done, or at a "${", giving a token of
+// type open; the lexer then goes on with the embedded expression. A
+// malformed string is returned as an ILLEGAL token whose literal
+// describes the problem.
+func (lex *Lexer) readString(done, open token.TokenType) (string, token.TokenType) {
+
+	pos := lex.position + 1
+	for {
+		lex.readChar()
+		switch lex.char {
+		case 0:
+			return "unterminated string literal", token.ILLEGAL
+		case '\\':
+			lex.readChar()
+			if lex.char == 0 {
+				return "unterminated string literal", token.ILLEGAL
+			}
+		case '$':
+			if lex.peekChar() == '{' {
+				text, tokenType := unescape(lex.input[pos:lex.position])
+				lex.readChar()
+				lex.interpolations = append(lex.interpolations, 0)
+				return text, withType(tokenType, open)
+			}
+		case '"':
+			text, tokenType := unescape(lex.input[pos:lex.position])
+			return text, withType(tokenType, done)
+		}
+	}
+}
+
+// withType replaces STRING, the type of a successfully read piece of
+// text, with the type the piece should have.
+func withType(tokenType, piece token.TokenType) token.TokenType {
+	if tokenType == token.STRING {
+		return piece
+	}
+	return tokenType
+}
+
+// readTextBlock reads a string delimited by triple quotes. It may span
+// several lines; the line break after the opening quotes, the
+// indentation of the closing quotes and the indentation common to
+// every line are removed, so the block can be indented with the code
+// around it. Escape sequences are resolved afterwards.
+func (lex *Lexer) readTextBlock() (string, token.TokenType) {
+
+	lex.readChar()
+	lex.readChar()
+
+	pos := lex.position + 1
+	for {
+		lex.readChar()
+		switch lex.char {
+		case 0:
+			return "unterminated multi-line string literal", token.ILLEGAL
+		case '\\':
+			lex.readChar()
+			if lex.char == 0 {
+				return "unterminated multi-line string literal", token.ILLEGAL
+			}
+		case '"':
+			if lex.peekChar() == '"' && lex.charAt(lex.readPosition+1) == '"' {
+				raw := lex.input[pos:lex.position]
+				lex.readChar()
+				lex.readChar()
+				return unescape(dedent(raw))
+			}
+		}
+	}
+}
+
+// readRawString reads a backtick-quoted string, which may span several
+// lines and is taken exactly as written: backslashes have no special
+// meaning.
+func (lex *Lexer) readRawString() (string, token.TokenType) {
+
+	pos := lex.position + 1
+	for {
+		lex.readChar()
+		switch lex.char {
+		case 0:
+			return "unterminated raw string literal", token.ILLEGAL
+		case '`':
+			return lex.input[pos:lex.position], token.STRING
+		}
+	}
+}
+
+var escapes = map[byte]byte{
+	'n':  '\n',
+	't':  '\t',
+	'r':  '\r',
+	'0':  0,
+	'\\': '\\',
+	'"':  '"',
+	'$':  '$',
+}
+
+// unescape resolves the escape sequences in the body of a string.
+func unescape(raw string) (string, token.TokenType) {
+
+	if !strings.Contains(raw, `\`) {
+		return raw, token.STRING
+	}
+
+	var out strings.Builder
+	for i := 0; i < len(raw); i++ {
+
+		if raw[i] != '\\' {
+			out.WriteByte(raw[i])
+			continue
+		}
+
+		// Dedenting a text block can leave a backslash at the very end.
+		i++
+		if i == len(raw) {
+			return "unterminated escape sequence", token.ILLEGAL
+		}
+		if ch, ok := escapes[raw[i]]; ok {
+			out.WriteByte(ch)
+			continue
+		}
+		if raw[i] != 'u' {
+			ch, _ := utf8.DecodeRuneInString(raw[i:])
+			return fmt.Sprintf("unknown escape sequence `\\%c`", ch), token.ILLEGAL
+		}
+
+		r, n := unicodeEscape(raw[i+1:])
+		if n == 0 {
+			return "invalid unicode escape, expected `\\u{hex}`", token.ILLEGAL
+		}
+		out.WriteRune(r)
+		i += n
+	}
+	return out.String(), token.STRING
+}
+
+// unicodeEscape decodes the "{1F42B}" part of a \u escape at the start
+// of s and returns the rune and the number of bytes it took, or 0 when
+// it is malformed or not a valid code point.
+func unicodeEscape(s string) (rune, int) {
+
+	end := strings.IndexByte(s, '}')
+	if !strings.HasPrefix(s, "{") || end < 2 || end > 7 {
+		return 0, 0
+	}
+
+	code, err := strconv.ParseUint(s[1:end], 16, 32)
+	if err != nil || !utf8.ValidRune(rune(code)) {
+		return 0, 0
+	}
+	return rune(code), end + 1
+}
+
+// dedent strips the line break after the opening delimiter, the line
+// holding the closing delimiter when it is blank, and the indentation
+// shared by every non-blank line.
+func dedent(s string) string {
+
+	lines := strings.Split(s, "\n")
+	if len(lines) > 1 && isBlank(lines[0]) {
+		lines = lines[1:]
+	}
+	if len(lines) > 1 && isBlank(lines[len(lines)-1]) {
+		lines = lines[:len(lines)-1]
+	}
+
+	indent := -1
+	for _, line := range lines {
+		if isBlank(line) {
+			continue
+		}
+		width := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent < 0 || width < indent {
+			indent = width
+		}
+	}
+
+	for i, line := range lines {
+		if isBlank(line) {
+			lines[i] = ""
+		} else {
+			lines[i] = line[indent:]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func isBlank(line string) bool {
+	return strings.TrimLeft(line, " \t") == ""
+}
@@ -1,22 +0,0 @@
-package main
-
-import (
-	"camel/repl"
-	"fmt"
-	"os"
-	"os/user"
-)
-
-func main() {
-	user, err := user.Current()
-	if err != nil {
-		panic(err)
-	}
-
-	fmt.Println(repl.Logo)
-	fmt.Printf("Hello! %s, This is camel programming language!\n",
-		user.Username)
-	fmt.Printf("Feel free to type in commands\n")
-
-	repl.Start(os.Stdin, os.Stdout)
-}
@@ -3,25 +3,44 @@ package object
 import (
 	"bytes"
 	"camel/ast"
+	"camel/token"
 	"fmt"
 	"hash/fnv"
+	"math"
+	"sort"
+	"strconv"
 	"strings"
 )
 
 type ObjectType string
-type BuiltinFunction func(args ...Object) Object
+type BuiltinFunction func(e Evaluator, args ...Object) Object
+
+// Evaluator lets a builtin call back into the interpreter running it.
+type Evaluator interface {
+	// Apply calls fn, a camel function or a builtin, with args and
+	// returns its result.
+	Apply(fn Object, args ...Object) Object
+	// Step counts one unit of work done by a builtin, such as a turn
+	// of a long loop, against the interpreter's limits. It returns an
+	// error once the program has to stop.
+	Step() *Error
+}
 
 const (
 	INTEGER_OBJ      = "INTEGER"
+	FLOAT_OBJ        = "FLOAT"
 	STRING_OBJ       = "STRING"
 	BOOLEAN_OBJ      = "BOOLEAN"
 	ARRAY_OBJ        = "ARRAY"
 	HASH_OBJ         = "HASH"
 	NULL             = "NULL"
 	RETURN_VALUE_OBJ = "RETURN_VALUE"
+	BREAK_OBJ        = "BREAK"
+	CONTINUE_OBJ     = "CONTINUE"
 	ERROR_OBJ        = "ERROR"
 	FUNCTION_OBJ     = "FUNCTION"
 	BUILTIN_OBJ      = "BUILTIN"
+	TAIL_CALL_OBJ    = "TAIL_CALL"
 )
 
 type Object interface {
@@ -40,6 +59,32 @@ func (i *Integer) Inspect() string {
 	return fmt.Sprintf("%d", i.Value)
 }
 
+type Float struct {
+	Value float64
+}
+
+func (f *Float) Type() ObjectType {
+	return FLOAT_OBJ
+}
+
+// Inspect formats the float in its shortest exact form, switching to
+// an exponent only for very large or very small numbers. A float
+// always shows a fraction or an exponent, so 2.0 is not mistaken for
+// the integer 2.
+func (f *Float) Inspect() string {
+
+	format := byte('f')
+	if abs := math.Abs(f.Value); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		format = 'g'
+	}
+
+	s := strconv.FormatFloat(f.Value, format, -1, 64)
+	if !strings.ContainsAny(s, ".eIN") {
+		s += ".0"
+	}
+	return s
+}
+
 type String struct {
 	Value string
 }
@@ -84,18 +129,127 @@ func (r *ReturnValue) Inspect() string {
 	return r.Value.Inspect()
 }
 
+// Break and Continue are signals a loop body returns to the loop
+// evaluating it, the same way ReturnValue unwinds a function body.
+type Break struct{}
+
+func (b *Break) Type() ObjectType {
+	return BREAK_OBJ
+}
+func (b *Break) Inspect() string {
+	return "break"
+}
+
+type Continue struct{}
+
+func (c *Continue) Type() ObjectType {
+	return CONTINUE_OBJ
+}
+func (c *Continue) Inspect() string {
+	return "continue"
+}
+
+// TailCall is a call in tail position that a function body returns
+// instead of making, so the function that is returning can make it in
+// its own place and recursion doesn't grow the stack.
+type TailCall struct {
+	Function  *Function
+	Arguments []Object
+	Pos       token.Position
+}
+
+func (t *TailCall) Type() ObjectType {
+	return TAIL_CALL_OBJ
+}
+func (t *TailCall) Inspect() string {
+	return "tail call"
+}
+
+// Frame is a function call that was in progress when an error
+// occurred: the name of the function, if known, and the call site.
+type Frame struct {
+	Function string
+	Pos      token.Position
+	// TailCalls counts the calls this frame took the place of by being
+	// made in tail position, which the traceback no longer shows.
+	TailCalls int
+}
+
+func (f Frame) String() string {
+	name := f.Function
+	if name == "" {
+		name = "<anonymous>"
+	}
+	switch f.TailCalls {
+	case 0:
+		return fmt.Sprintf("at %s (%s)", name, f.Pos)
+	case 1:
+		return fmt.Sprintf("at %s (%s) [via 1 tail call]", name, f.Pos)
+	default:
+		return fmt.Sprintf("at %s (%s) [via %d tail calls]", name, f.Pos, f.TailCalls)
+	}
+}
+
+type ErrorKind string
+
+const (
+	// RUNTIME_ERROR is an ordinary failure such as a type mismatch.
+	RUNTIME_ERROR ErrorKind = "RUNTIME"
+	// EXIT_ERROR unwinds the program after a call to exit(). Code
+	// holds the requested exit status.
+	EXIT_ERROR ErrorKind = "EXIT"
+	// LIMIT_ERROR stops a program that went over one of the
+	// evaluator's limits, such as its step budget.
+	LIMIT_ERROR ErrorKind = "LIMIT"
+	// CANCELED_ERROR stops a program whose context was canceled or
+	// reached its deadline.
+	CANCELED_ERROR ErrorKind = "CANCELED"
+)
+
+// stackEnds is how many frames Error.Inspect prints from either end
+// of a long stack.
+const stackEnds = 10
+
 type Error struct {
+	Kind    ErrorKind
 	Message string
+	Code    int
+	Pos     token.Position
+	// Stack holds the calls leading to the error, innermost first.
+	Stack []Frame
 }
 
 func (e *Error) Type() ObjectType {
 	return ERROR_OBJ
 }
 func (e *Error) Inspect() string {
-	return "Error: " + e.Message
+
+	var out bytes.Buffer
+
+	out.WriteString("Error: ")
+	if e.Pos.IsValid() {
+		out.WriteString(e.Pos.String() + ": ")
+	}
+	out.WriteString(e.Message)
+
+	// A runaway recursion leaves thousands of frames, so only both ends
+	// of a long stack are printed.
+	for i, frame := range e.Stack {
+		if len(e.Stack) > 2*stackEnds && i == stackEnds {
+			fmt.Fprintf(&out, "\n    ... %d more calls",
+				len(e.Stack)-2*stackEnds)
+		}
+		if len(e.Stack) > 2*stackEnds && i >= stackEnds && i < len(e.Stack)-stackEnds {
+			continue
+		}
+		out.WriteString("\n    " + frame.String())
+	}
+
+	return out.String()
 }
 
 type Function struct {
+	Name       string
 	Parameters []*ast.Identifier
 	Body       *ast.BlockStatement
 	Env        *Environment
@@ -145,18 +299,59 @@ func (a *Array) Type() ObjectType {
 	return ARRAY_OBJ
 }
 func (a *Array) Inspect() string {
+	return inspect(a, map[Object]bool{})
+}
 
-	var out bytes.Buffer
+// inspect formats obj like Inspect. seen holds the arrays and hashes
+// being printed around obj, so a container that holds itself prints
+// as [...] or {...} instead of recursing forever.
+func inspect(obj Object, seen map[Object]bool) string {
 
-	elems := []string{}
-	for _, e := range a.Elements {
-		elems = append(elems, e.Inspect())
-	}
-	out.WriteString("[")
-	out.WriteString(strings.Join(elems, ", "))
-	out.WriteString("]")
+	switch obj := obj.(type) {
+	case *Array:
+		if seen[obj] {
+			return "[...]"
+		}
+		seen[obj] = true
+		defer delete(seen, obj)
 
-	return out.String()
+		var out bytes.Buffer
+
+		elems := []string{}
+		for _, e := range obj.Elements {
+			elems = append(elems, inspect(e, seen))
+		}
+		out.WriteString("[")
+		out.WriteString(strings.Join(elems, ", "))
+		out.WriteString("]")
+
+		return out.String()
+
+	case *Hash:
+		if seen[obj] {
+			return "{...}"
+		}
+		seen[obj] = true
+		defer delete(seen, obj)
+
+		var out bytes.Buffer
+
+		pairs := []string{}
+
+		for _, p := range obj.SortedPairs() {
+			pairs = append(pairs, fmt.Sprintf("%s : %s",
+				inspect(p.Key, seen), inspect(p.Value, seen)))
+		}
+
+		out.WriteString("{")
+		out.WriteString(strings.Join(pairs, ", "))
+		out.WriteString("}")
+
+		return out.String()
+
+	default:
+		return obj.Inspect()
+	}
 }
 
 type Hashable interface {
@@ -183,6 +378,17 @@ func (b *Boolean) HashKey() HashKey {
 func (i *Integer) HashKey() HashKey {
 	return HashKey{Type: i.Type(), Value: uint64(i.Value)}
 }
+
+// HashKey makes a whole float the same key as the integer it equals,
+// so that 1.0 finds what was stored under 1.
+func (f *Float) HashKey() HashKey {
+
+	value := f.Value
+	if value == math.Trunc(value) && value >= math.MinInt64 && value < math.MaxInt64 {
+		return (&Integer{Value: int64(value)}).HashKey()
+	}
+	return HashKey{Type: f.Type(), Value: math.Float64bits(value)}
+}
 func (s *String) HashKey() HashKey {
 
 	h := fnv.New64a()
@@ -205,19 +411,40 @@ func (h *Hash) Type() ObjectType {
 }
 
 func (h *Hash) Inspect() string {
+	return inspect(h, map[Object]bool{})
+}
 
-	var out bytes.Buffer
-
-	pairs := []string{}
+// SortedPairs returns the pairs of the hash ordered by key, so that
+// printing and iterating a hash is deterministic.
+func (h *Hash) SortedPairs() []HashPair {
 
+	pairs := make([]HashPair, 0, len(h.Pairs))
 	for _, p := range h.Pairs {
-		pairs = append(pairs, fmt.Sprintf("%s : %s",
-			p.Key.Inspect(), p.Value.Inspect()))
+		pairs = append(pairs, p)
 	}
 
-	out.WriteString("{")
-	out.WriteString(strings.Join(pairs, ", "))
-	out.WriteString("}")
+	sort.Slice(pairs, func(i, j int) bool {
+		return lessKey(pairs[i].Key, pairs[j].Key)
+	})
+	return pairs
+}
 
-	return out.String()
+func lessKey(a, b Object) bool {
+
+	if a.Type() != b.Type() {
+		return a.Type() < b.Type()
+	}
+
+	switch a := a.(type) {
+	case *Integer:
+		return a.Value < b.(*Integer).Value
+	case *Float:
+		return a.Value < b.(*Float).Value
+	case *String:
+		return a.Value < b.(*String).Value
+	case *Boolean:
+		return !a.Value && b.(*Boolean).Value
+	default:
+		return false
+	}
 }
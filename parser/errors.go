@@ -0,0 +1,80 @@
+package parser
+
+import (
+	"camel/token"
+	"fmt"
+	"strconv"
+)
+
+// Error is a syntax error found while parsing, located at Pos.
+type Error struct {
+	Pos     token.Position
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Pos.String() + ": " + e.Message
+}
+
+// ErrorList holds every error reported while parsing a program, in
+// the order they were found.
+type ErrorList []*Error
+
+func (l ErrorList) Error() string {
+
+	switch len(l) {
+	case 0:
+		return "no errors"
+	case 1:
+		return l[0].Error()
+	case 2:
+		return fmt.Sprintf("%s (and 1 more error)", l[0])
+	}
+	return fmt.Sprintf("%s (and %d more errors)", l[0], len(l)-1)
+}
+
+// Err returns the list as an error, or nil when it is empty.
+func (l ErrorList) Err() error {
+	if len(l) == 0 {
+		return nil
+	}
+	return l
+}
+
+func describeType(tokenType token.TokenType) string {
+
+	switch tokenType {
+	case token.IDENT:
+		return "identifier"
+	case token.INT:
+		return "integer"
+	case token.FLOAT:
+		return "float"
+	case token.STRING:
+		return "string"
+	case token.EOF:
+		return "end of input"
+	default:
+		return "`" + string(tokenType) + "`"
+	}
+}
+
+func describe(tok token.Token) string {
+
+	switch tok.Type {
+	case token.IDENT, token.INT, token.FLOAT:
+		return describeType(tok.Type) + " `" + tok.Literal + "`"
+	case token.STRING:
+		return "string " + strconv.Quote(tok.Literal)
+	case token.INTERP_START:
+		return "string"
+	case token.ILLEGAL:
+		return tok.Literal
+	case token.INTERP_MID, token.INTERP_END:
+		return "`}`"
+	case token.EOF:
+		return "end of input"
+	default:
+		return "`" + tok.Literal + "`"
+	}
+}
@@ -11,26 +11,66 @@ import (
 const (
 	_ int = iota
 	LOWEST
+	ASSIGNMENT
+	NULLISH
+	LOGICAL_OR
+	LOGICAL_AND
+	BITWISE_OR
+	BITWISE_XOR
+	BITWISE_AND
 	EQUALS
 	LESSGREATER
+	SHIFT
 	SUM
 	PRODUCT
 	PREFIX
+	POWER
 	CALL
 	INDEX
 )
 
 var precedences = map[token.TokenType]int{
+	token.NULLISH:        NULLISH,
+	token.OPTIONAL_INDEX: INDEX,
+	token.OPTIONAL_CALL:  CALL,
+
+	token.ASSIGN:          ASSIGNMENT,
+	token.PLUS_ASSIGN:     ASSIGNMENT,
+	token.MINUS_ASSIGN:    ASSIGNMENT,
+	token.ASTERISK_ASSIGN: ASSIGNMENT,
+	token.SLASH_ASSIGN:    ASSIGNMENT,
+
+	token.PERCENT_ASSIGN:     ASSIGNMENT,
+	token.POWER_ASSIGN:       ASSIGNMENT,
+	token.BIT_AND_ASSIGN:     ASSIGNMENT,
+	token.BIT_OR_ASSIGN:      ASSIGNMENT,
+	token.BIT_XOR_ASSIGN:     ASSIGNMENT,
+	token.SHIFT_LEFT_ASSIGN:  ASSIGNMENT,
+	token.SHIFT_RIGHT_ASSIGN: ASSIGNMENT,
+
 	token.EQ:     EQUALS,
 	token.NOT_EQ: EQUALS,
 
 	token.ASTERISK: PRODUCT,
 	token.SLASH:    PRODUCT,
+	token.PERCENT:  PRODUCT,
 	token.PLUS:     SUM,
 	token.MINUS:    SUM,
+	token.POWER:    POWER,
+
+	token.BIT_AND:     BITWISE_AND,
+	token.BIT_XOR:     BITWISE_XOR,
+	token.BIT_OR:      BITWISE_OR,
+	token.SHIFT_LEFT:  SHIFT,
+	token.SHIFT_RIGHT: SHIFT,
 
-	token.LT: LESSGREATER,
-	token.GT: LESSGREATER,
+	token.AND: LOGICAL_AND,
+	token.OR:  LOGICAL_OR,
+
+	token.LT:    LESSGREATER,
+	token.GT:    LESSGREATER,
+	token.LT_EQ: LESSGREATER,
+	token.GT_EQ: LESSGREATER,
 
 	token.LPAREN:   CALL,
 	token.LBRACKET: INDEX,
@@ -48,6 +88,17 @@ type Parser struct {
 
 	prefixParseFns map[token.TokenType]prefixParseFn
 	infixParseFns  map[token.TokenType]infixParseFn
+
+	errors ErrorList
+	// panicking is set after an error until the parser has skipped to
+	// the next statement, so one mistake is only reported once.
+	panicking bool
+	// loopDepth counts the loop bodies being parsed, so break and
+	// continue can be rejected outside of them.
+	loopDepth int
+	// blockDepth counts the blocks being parsed. Outside of any, a `}`
+	// closes nothing and is skipped when recovering from an error.
+	blockDepth int
 }
 
 func (p *Parser) registerPrefix(tokenType token.TokenType, fn prefixParseFn) {
@@ -68,14 +119,19 @@ func New(lex *lexer.Lexer) *Parser {
 	parser.infixParseFns = make(map[token.TokenType]infixParseFn)
 	parser.registerPrefix(token.IDENT, parser.parseIdentifier)
 	parser.registerPrefix(token.INT, parser.parseIntegerLiteral)
+	parser.registerPrefix(token.FLOAT, parser.parseFloatLiteral)
 	parser.registerPrefix(token.MINUS, parser.parsePrefixExpression)
 	parser.registerPrefix(token.BANG, parser.parsePrefixExpression)
+	parser.registerPrefix(token.BIT_NOT, parser.parsePrefixExpression)
 	parser.registerPrefix(token.TRUE, parser.parseBoolean)
 	parser.registerPrefix(token.FALSE, parser.parseBoolean)
 	parser.registerPrefix(token.LPAREN, parser.parseGroupedExpression)
 	parser.registerPrefix(token.IF, parser.parseIfExpression)
 	parser.registerPrefix(token.FUNCTION, parser.parseFunctionLiterals)
 	parser.registerPrefix(token.STRING, parser.parseStringLiteral)
+	parser.registerPrefix(token.INTERP_START, parser.parseInterpolatedString)
+	parser.registerPrefix(token.ILLEGAL, parser.parseIllegal)
+	parser.registerPrefix(token.NULL, parser.parseNullLiteral)
 	parser.registerPrefix(token.LBRACKET, parser.parseArrayLiteral)
 	parser.registerPrefix(token.LBRACE, parser.parseHashLiteral)
 
@@ -83,16 +139,97 @@ func New(lex *lexer.Lexer) *Parser {
 	parser.registerInfix(token.MINUS, parser.parseInfixExpression)
 	parser.registerInfix(token.ASTERISK, parser.parseInfixExpression)
 	parser.registerInfix(token.SLASH, parser.parseInfixExpression)
+	parser.registerInfix(token.PERCENT, parser.parseInfixExpression)
+	parser.registerInfix(token.POWER, parser.parseInfixExpression)
+	parser.registerInfix(token.BIT_AND, parser.parseInfixExpression)
+	parser.registerInfix(token.BIT_OR, parser.parseInfixExpression)
+	parser.registerInfix(token.BIT_XOR, parser.parseInfixExpression)
+	parser.registerInfix(token.SHIFT_LEFT, parser.parseInfixExpression)
+	parser.registerInfix(token.SHIFT_RIGHT, parser.parseInfixExpression)
+	parser.registerInfix(token.NULLISH, parser.parseInfixExpression)
+	parser.registerInfix(token.OPTIONAL_INDEX, parser.parseIndexExpression)
+	parser.registerInfix(token.OPTIONAL_CALL, parser.parseCallExpression)
 	parser.registerInfix(token.LT, parser.parseInfixExpression)
 	parser.registerInfix(token.GT, parser.parseInfixExpression)
+	parser.registerInfix(token.LT_EQ, parser.parseInfixExpression)
+	parser.registerInfix(token.GT_EQ, parser.parseInfixExpression)
+	parser.registerInfix(token.AND, parser.parseInfixExpression)
+	parser.registerInfix(token.OR, parser.parseInfixExpression)
 	parser.registerInfix(token.EQ, parser.parseInfixExpression)
 	parser.registerInfix(token.NOT_EQ, parser.parseInfixExpression)
+	parser.registerInfix(token.ASSIGN, parser.parseAssignExpression)
+	parser.registerInfix(token.PLUS_ASSIGN, parser.parseAssignExpression)
+	parser.registerInfix(token.MINUS_ASSIGN, parser.parseAssignExpression)
+	parser.registerInfix(token.ASTERISK_ASSIGN, parser.parseAssignExpression)
+	parser.registerInfix(token.SLASH_ASSIGN, parser.parseAssignExpression)
+	parser.registerInfix(token.PERCENT_ASSIGN, parser.parseAssignExpression)
+	parser.registerInfix(token.POWER_ASSIGN, parser.parseAssignExpression)
+	parser.registerInfix(token.BIT_AND_ASSIGN, parser.parseAssignExpression)
+	parser.registerInfix(token.BIT_OR_ASSIGN, parser.parseAssignExpression)
+	parser.registerInfix(token.BIT_XOR_ASSIGN, parser.parseAssignExpression)
+	parser.registerInfix(token.SHIFT_LEFT_ASSIGN, parser.parseAssignExpression)
+	parser.registerInfix(token.SHIFT_RIGHT_ASSIGN, parser.parseAssignExpression)
 	parser.registerInfix(token.LPAREN, parser.parseCallExpression)
 	parser.registerInfix(token.LBRACKET, parser.parseIndexExpression)
 
 	return parser
 }
 
+// Errors returns the syntax errors found by ParseProgram. A program
+// with errors is incomplete and must not be evaluated.
+func (p *Parser) Errors() ErrorList {
+	return p.errors
+}
+
+func (p *Parser) errorf(pos token.Position, format string, a ...interface{}) {
+
+	if p.panicking {
+		return
+	}
+	p.panicking = true
+	p.errors = append(p.errors, &Error{Pos: pos, Message: fmt.Sprintf(format, a...)})
+}
+
+func (p *Parser) peekError(tok token.TokenType) {
+	p.errorf(p.peekToken.Pos, "expected %s but got %s",
+		describeType(tok), describe(p.peekToken))
+}
+
+// synchronize skips tokens until the end of the statement that failed
+// to parse, leaving the current token where the statement loops expect
+// the last token of a statement to be.
+func (p *Parser) synchronize() {
+
+	depth := 0
+	for !p.curTokenIs(token.EOF) {
+
+		switch p.curToken.Type {
+		case token.LBRACE:
+			depth++
+		case token.RBRACE:
+			if depth > 0 {
+				depth--
+			}
+		case token.SEMICOLON:
+			if depth == 0 {
+				return
+			}
+		}
+
+		if depth == 0 {
+			switch p.peekToken.Type {
+			case token.LET, token.RETURN, token.EOF:
+				return
+			case token.RBRACE:
+				if p.blockDepth > 0 {
+					return
+				}
+			}
+		}
+		p.nextToken()
+	}
+}
+
 func (p *Parser) nextToken() {
 
 	p.curToken = p.peekToken
@@ -112,20 +249,45 @@ func (p *Parser) ParseProgram() *ast.Program {
 		}
 		p.nextToken()
 	}
+
+	for _, comment := range p.lex.Comments() {
+		program.Comments = append(program.Comments, &ast.Comment{Token: comment})
+	}
 	return program
 }
 
 func (p *Parser) parseStatement() ast.Statement {
 
+	var stmt ast.Statement
+	errors := len(p.errors)
+
 	switch p.curToken.Type {
 
 	case token.LET:
-		return p.parseLetStatement()
+		stmt = p.parseLetStatement()
 	case token.RETURN:
-		return p.parseReturn()
+		stmt = p.parseReturn()
+	case token.WHILE:
+		stmt = p.parseWhileStatement()
+	case token.FOR:
+		stmt = p.parseForStatement()
+	case token.BREAK:
+		stmt = p.parseBreakStatement()
+	case token.CONTINUE:
+		stmt = p.parseContinueStatement()
 	default:
-		return p.parseExpressionStatement()
+		stmt = p.parseExpressionStatement()
+	}
+
+	if len(p.errors) > errors {
+		// A nested block may already have recovered from the error.
+		if p.panicking {
+			p.synchronize()
+			p.panicking = false
+		}
+		return nil
 	}
+	return stmt
 }
 
 func (p *Parser) parseLetStatement() *ast.LetStatement {
@@ -146,6 +308,10 @@ func (p *Parser) parseLetStatement() *ast.LetStatement {
 
 	stmt.Value = p.parseExpression(LOWEST)
 
+	if fl, ok := stmt.Value.(*ast.FunctionLiteral); ok {
+		fl.Name = stmt.Name.Value
+	}
+
 	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
 	}
@@ -168,6 +334,154 @@ func (p *Parser) parseReturn() *ast.ReturnStatement {
 	return stmt
 }
 
+func (p *Parser) parseWhileStatement() *ast.WhileStatement {
+
+	stmt := &ast.WhileStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	p.nextToken()
+	stmt.Condition = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	stmt.Body = p.parseLoopBody()
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+	return stmt
+}
+
+func (p *Parser) parseForStatement() ast.Statement {
+
+	tok := p.curToken
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+	p.nextToken()
+
+	if p.curTokenIs(token.IDENT) && p.peekTokenIs(token.IN) {
+		return p.parseForInStatement(tok)
+	}
+
+	stmt := &ast.ForStatement{Token: tok}
+
+	if !p.curTokenIs(token.SEMICOLON) {
+		if p.curTokenIs(token.LET) {
+			stmt.Init = p.parseLetStatement()
+		} else {
+			stmt.Init = p.parseExpressionStatement()
+		}
+		if !p.curTokenIs(token.SEMICOLON) && !p.expectPeek(token.SEMICOLON) {
+			return nil
+		}
+	}
+
+	if !p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+		stmt.Condition = p.parseExpression(LOWEST)
+	}
+	if !p.expectPeek(token.SEMICOLON) {
+		return nil
+	}
+
+	if !p.peekTokenIs(token.RPAREN) {
+		p.nextToken()
+		if p.curTokenIs(token.LET) {
+			stmt.Post = p.parseLetStatement()
+		} else {
+			stmt.Post = &ast.ExpressionStatement{
+				Token:      p.curToken,
+				Expression: p.parseExpression(LOWEST),
+			}
+		}
+	}
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	stmt.Body = p.parseLoopBody()
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+	return stmt
+}
+
+func (p *Parser) parseForInStatement(tok token.Token) *ast.ForInStatement {
+
+	stmt := &ast.ForInStatement{Token: tok}
+	stmt.Variable = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	p.nextToken()
+	p.nextToken()
+	stmt.Iterable = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	stmt.Body = p.parseLoopBody()
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+	return stmt
+}
+
+func (p *Parser) parseLoopBody() *ast.BlockStatement {
+
+	p.loopDepth++
+	body := p.parseBlockStatement()
+	p.loopDepth--
+
+	return body
+}
+
+func (p *Parser) parseBreakStatement() *ast.BreakStatement {
+
+	stmt := &ast.BreakStatement{Token: p.curToken}
+	if p.loopDepth == 0 {
+		p.errorf(p.curToken.Pos, "`%s` outside of a loop", p.curToken.Literal)
+		return nil
+	}
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+	return stmt
+}
+
+func (p *Parser) parseContinueStatement() *ast.ContinueStatement {
+
+	stmt := &ast.ContinueStatement{Token: p.curToken}
+	if p.loopDepth == 0 {
+		p.errorf(p.curToken.Pos, "`%s` outside of a loop", p.curToken.Literal)
+		return nil
+	}
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+	return stmt
+}
+
 func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
 
 	stmt := &ast.ExpressionStatement{Token: p.curToken}
@@ -185,8 +499,7 @@ func (p *Parser) parseExpression(precedence int) ast.Expression {
 
 	prefixFunc := p.prefixParseFns[p.curToken.Type]
 	if prefixFunc == nil {
-		fmt.Printf("no prefix function registered for %v",
-			p.curToken.Type)
+		p.errorf(p.curToken.Pos, "unexpected %s", describe(p.curToken))
 		return nil
 	}
 
@@ -213,13 +526,66 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 	lit := &ast.IntegerLiteral{Token: p.curToken}
 	value, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
 	if err != nil {
-		fmt.Errorf(err.Error())
+		p.errorf(p.curToken.Pos, "could not parse %q as integer",
+			p.curToken.Literal)
+		return nil
+	}
+	lit.Value = value
+	return lit
+}
+
+func (p *Parser) parseFloatLiteral() ast.Expression {
+
+	lit := &ast.FloatLiteral{Token: p.curToken}
+	value, err := strconv.ParseFloat(p.curToken.Literal, 64)
+	if err != nil {
+		p.errorf(p.curToken.Pos, "could not parse %q as float",
+			p.curToken.Literal)
 		return nil
 	}
 	lit.Value = value
 	return lit
 }
 
+func (p *Parser) parseInterpolatedString() ast.Expression {
+
+	str := &ast.InterpolatedString{Token: p.curToken}
+	str.Parts = append(str.Parts, p.parseStringLiteral())
+
+	for {
+		p.nextToken()
+		part := p.parseExpression(LOWEST)
+		if part == nil {
+			return nil
+		}
+		str.Parts = append(str.Parts, part)
+
+		if !p.peekTokenIs(token.INTERP_MID) && !p.peekTokenIs(token.INTERP_END) {
+			p.errorf(p.peekToken.Pos, "expected `}` but got %s",
+				describe(p.peekToken))
+			return nil
+		}
+		p.nextToken()
+		str.Parts = append(str.Parts, p.parseStringLiteral())
+
+		if p.curTokenIs(token.INTERP_END) {
+			str.Closing = p.curToken
+			return str
+		}
+	}
+}
+
+func (p *Parser) parseNullLiteral() ast.Expression {
+	return &ast.NullLiteral{Token: p.curToken}
+}
+
+// parseIllegal reports a token the lexer could not make sense of; its
+// literal describes the problem.
+func (p *Parser) parseIllegal() ast.Expression {
+	p.errorf(p.curToken.Pos, "%s", p.curToken.Literal)
+	return nil
+}
+
 func (p *Parser) parseStringLiteral() ast.Expression {
 	return &ast.StringLiteral{
 		Token: p.curToken,
@@ -232,6 +598,7 @@ func (p *Parser) parseArrayLiteral() ast.Expression {
 
 	array := &ast.ArrayLiteral{Token: p.curToken}
 	array.Elements = p.parseExpressionList(token.RBRACKET)
+	array.Rbracket = p.curToken
 
 	return array
 }
@@ -264,13 +631,51 @@ func (p *Parser) parseExpressionList(end token.TokenType) []ast.Expression {
 func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
 
 	exp := &ast.IndexExpression{Token: p.curToken, Left: left}
+	exp.Optional = p.curTokenIs(token.OPTIONAL_INDEX)
+
+	if p.peekTokenIs(token.COLON) {
+		return p.parseSliceExpression(exp, nil)
+	}
 
 	p.nextToken()
 	exp.Index = p.parseExpression(LOWEST)
 
+	if p.peekTokenIs(token.COLON) {
+		return p.parseSliceExpression(exp, exp.Index)
+	}
+
 	if !p.expectPeek(token.RBRACKET) {
 		return nil
 	}
+	exp.Rbracket = p.curToken
+
+	return exp
+}
+
+// parseSliceExpression parses the rest of left[low:high] once the
+// colon is the next token; low is nil when it was left out.
+func (p *Parser) parseSliceExpression(
+	index *ast.IndexExpression,
+	low ast.Expression,
+) ast.Expression {
+
+	exp := &ast.SliceExpression{
+		Token:    index.Token,
+		Left:     index.Left,
+		Low:      low,
+		Optional: index.Optional,
+	}
+	p.nextToken()
+
+	if !p.peekTokenIs(token.RBRACKET) {
+		p.nextToken()
+		exp.High = p.parseExpression(LOWEST)
+	}
+
+	if !p.expectPeek(token.RBRACKET) {
+		return nil
+	}
+	exp.Rbracket = p.curToken
 
 	return exp
 }
@@ -300,6 +705,7 @@ func (p *Parser) parseHashLiteral() ast.Expression {
 	}
 
 	p.nextToken()
+	hash.Rbrace = p.curToken
 	return hash
 }
 
@@ -325,12 +731,40 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 	}
 
 	precedence := p.curPrecedence()
+	// ** is right associative: 2 ** 3 ** 2 is 2 ** (3 ** 2).
+	if p.curTokenIs(token.POWER) {
+		precedence--
+	}
 	p.nextToken()
 	expression.Right = p.parseExpression(precedence)
 
 	return expression
 }
 
+func (p *Parser) parseAssignExpression(target ast.Expression) ast.Expression {
+
+	expression := &ast.AssignExpression{
+		Token:    p.curToken,
+		Operator: p.curToken.Literal,
+		Target:   target,
+	}
+
+	switch target.(type) {
+	case *ast.Identifier, *ast.IndexExpression:
+	case nil:
+		return nil
+	default:
+		p.errorf(target.Pos(), "cannot assign to %s", target.String())
+		return nil
+	}
+
+	// Assignment is right associative: a = b = c assigns c to both.
+	p.nextToken()
+	expression.Value = p.parseExpression(LOWEST)
+
+	return expression
+}
+
 func (p *Parser) parseBoolean() ast.Expression {
 	return &ast.Boolean{Token: p.curToken, Value: p.curTokenIs(token.TRUE)}
 }
@@ -366,6 +800,16 @@ func (p *Parser) parseIfExpression() ast.Expression {
 
 	if p.peekTokenIs(token.ELSE) {
 		p.nextToken()
+
+		if p.peekTokenIs(token.IF) {
+			p.nextToken()
+			expression.Alternative = p.parseElseIf()
+			if expression.Alternative == nil {
+				return nil
+			}
+			return expression
+		}
+
 		if !p.expectPeek(token.LBRACE) {
 			return nil
 		}
@@ -375,12 +819,38 @@ func (p *Parser) parseIfExpression() ast.Expression {
 	return expression
 }
 
+// parseElseIf parses the if expression following an `else` as a block
+// holding only that expression, so an else-if chain evaluates like the
+// nested ifs it stands for.
+func (p *Parser) parseElseIf() *ast.BlockStatement {
+
+	tok := p.curToken
+	nested, ok := p.parseIfExpression().(*ast.IfExpression)
+	if !ok {
+		return nil
+	}
+
+	last := nested.Consequence
+	if nested.Alternative != nil {
+		last = nested.Alternative
+	}
+
+	return &ast.BlockStatement{
+		Token: tok,
+		Statements: []ast.Statement{
+			&ast.ExpressionStatement{Token: tok, Expression: nested},
+		},
+		Rbrace: last.Rbrace,
+	}
+}
+
 func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 
 	block := &ast.BlockStatement{Token: p.curToken}
 	block.Statements = []ast.Statement{}
 
 	p.nextToken()
+	p.blockDepth++
 
 	for !p.curTokenIs(token.RBRACE) && !p.curTokenIs(token.EOF) {
 		stmt := p.parseStatement()
@@ -389,6 +859,8 @@ func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 		}
 		p.nextToken()
 	}
+	p.blockDepth--
+	block.Rbrace = p.curToken
 	return block
 }
 
@@ -404,26 +876,37 @@ func (p *Parser) parseFunctionLiterals() ast.Expression {
 		return nil
 	}
 
+	// Loops outside the function body can't be broken out of from
+	// inside it.
+	loopDepth := p.loopDepth
+	p.loopDepth = 0
 	foo.Body = p.parseBlockStatement()
+	p.loopDepth = loopDepth
+	markTailCalls(foo.Body)
+
 	return foo
 }
 
 func (p *Parser) parseFunctionParameters() []*ast.Identifier {
 
 	identifiers := []*ast.Identifier{}
-	for p.peekTokenIs(token.RPAREN) {
+	if p.peekTokenIs(token.RPAREN) {
 		p.nextToken()
-		return nil
+		return identifiers
 	}
 
-	p.nextToken()
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
 
 	ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 	identifiers = append(identifiers, ident)
 
 	for p.peekTokenIs(token.COMMA) {
 		p.nextToken()
-		p.nextToken()
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
 
 		ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 		identifiers = append(identifiers, ident)
@@ -437,7 +920,9 @@ func (p *Parser) parseFunctionParameters() []*ast.Identifier {
 
 func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
 	exp := &ast.CallExpression{Token: p.curToken, Function: function}
+	exp.Optional = p.curTokenIs(token.OPTIONAL_CALL)
 	exp.Arguments = p.parseExpressionList(token.RPAREN)
+	exp.Rparen = p.curToken
 
 	return exp
 }
@@ -455,6 +940,7 @@ func (p *Parser) expectPeek(tok token.TokenType) bool {
 		p.nextToken()
 		return true
 	} else {
+		p.peekError(tok)
 		return false
 	}
 }
@@ -4,6 +4,7 @@ import (
 	"camel/ast"
 	"camel/lexer"
 	"fmt"
+	"strings"
 	"testing"
 )
 
@@ -135,6 +136,33 @@ func TestIntegerLiteralExpression(t *testing.T) {
 	}
 }
 
+func TestFloatLiteralExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"3.25;", 3.25},
+		{"1e3", 1000},
+		{"2.5e-1", 0.25},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		literal, ok := stmt.Expression.(*ast.FloatLiteral)
+		if !ok {
+			t.Fatalf("exp not *ast.FloatLiteral. got=%T", stmt.Expression)
+		}
+		if literal.Value != tt.expected {
+			t.Errorf("literal.Value not %g. got=%g", tt.expected, literal.Value)
+		}
+	}
+}
+
 func TestParsingPrefixExpressions(t *testing.T) {
 	prefixTests := []struct {
 		input    string
@@ -194,6 +222,10 @@ func TestParsingInfixExpressions(t *testing.T) {
 		{"5 < 5;", 5, "<", 5},
 		{"5 == 5;", 5, "==", 5},
 		{"5 != 5;", 5, "!=", 5},
+		{"5 <= 5;", 5, "<=", 5},
+		{"5 >= 5;", 5, ">=", 5},
+		{"true && false", true, "&&", false},
+		{"true || false", true, "||", false},
 		{"foobar + barfoo;", "foobar", "+", "barfoo"},
 		{"foobar - barfoo;", "foobar", "-", "barfoo"},
 		{"foobar * barfoo;", "foobar", "*", "barfoo"},
@@ -343,6 +375,90 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"add(a * b[2], b[1], 2 * [1, 2][1])",
 			"add((a * (b[2])), (b[1]), (2 * ([1, 2][1])))",
 		},
+		{
+			"x = y + 1",
+			"x = (y + 1)",
+		},
+		{
+			"a || b && c",
+			"(a || (b && c))",
+		},
+		{
+			"a && b || c && d",
+			"((a && b) || (c && d))",
+		},
+		{
+			"a < b && c >= d == true",
+			"((a < b) && ((c >= d) == true))",
+		},
+		{
+			"x = a || b",
+			"x = (a || b)",
+		},
+		{
+			"a = b = c * 2",
+			"a = b = (c * 2)",
+		},
+		{
+			"a[i + 1] += 2 == 3",
+			"(a[(i + 1)]) += (2 == 3)",
+		},
+		{
+			"a + b % c",
+			"(a + (b % c))",
+		},
+		{
+			"2 ** 3 ** 2",
+			"(2 ** (3 ** 2))",
+		},
+		{
+			"-2 ** 2",
+			"(-(2 ** 2))",
+		},
+		{
+			"a * b ** c",
+			"(a * (b ** c))",
+		},
+		{
+			"~a & b",
+			"((~a) & b)",
+		},
+		{
+			"a | b ^ c & d",
+			"(a | (b ^ (c & d)))",
+		},
+		{
+			"a & b == c",
+			"(a & (b == c))",
+		},
+		{
+			"a << b + c < d",
+			"((a << (b + c)) < d)",
+		},
+		{
+			"a || b | c",
+			"(a || (b | c))",
+		},
+		{
+			"x <<= a | b",
+			"x <<= (a | b)",
+		},
+		{
+			"a ?? b || c",
+			"(a ?? (b || c))",
+		},
+		{
+			"x = a ?? b ?? c",
+			"x = ((a ?? b) ?? c)",
+		},
+		{
+			`h?["a"]?["b"] ?? f?(1)`,
+			`(((h?[a])?[b]) ?? f?(1))`,
+		},
+		{
+			"a == null",
+			"(a == null)",
+		},
 	}
 
 	for _, tt := range tests {
@@ -377,6 +493,53 @@ func TestParsingIndexExpressions(t *testing.T) {
 		return
 	}
 }
+
+func TestParsingSliceExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		low      interface{}
+		high     interface{}
+		expected string
+	}{
+		{"a[1:n]", 1, "n", "(a[1:n])"},
+		{"a[:2]", nil, 2, "(a[:2])"},
+		{"a[i:]", "i", nil, "(a[i:])"},
+		{"a[:]", nil, nil, "(a[:])"},
+		{"a?[2:]", 2, nil, "(a?[2:])"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		slice, ok := stmt.Expression.(*ast.SliceExpression)
+		if !ok {
+			t.Fatalf("exp not *ast.SliceExpression. got=%T", stmt.Expression)
+		}
+		if program.String() != tt.expected {
+			t.Errorf("wrong String(). want=%q, got=%q", tt.expected, program.String())
+		}
+		if !testIdentifier(t, slice.Left, "a") {
+			return
+		}
+
+		for _, bound := range []struct {
+			exp      ast.Expression
+			expected interface{}
+		}{{slice.Low, tt.low}, {slice.High, tt.high}} {
+			if bound.expected == nil {
+				if bound.exp != nil {
+					t.Errorf("%q: expected a missing bound, got %s", tt.input, bound.exp)
+				}
+				continue
+			}
+			testLiteralExpression(t, bound.exp, bound.expected)
+		}
+	}
+}
 func TestParsingEmptyHashLiteral(t *testing.T) {
 	input := "{}"
 
@@ -688,6 +851,61 @@ func TestIfElseExpression(t *testing.T) {
 	}
 }
 
+func TestElseIfExpression(t *testing.T) {
+	input := `if (x < 1) { a } else if (x < 2) { b } else if (x < 3) { c } else { d }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain 1 statements. got=%d",
+			len(program.Statements))
+	}
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	exp, ok := stmt.Expression.(*ast.IfExpression)
+	if !ok {
+		t.Fatalf("stmt.Expression is not ast.IfExpression. got=%T", stmt.Expression)
+	}
+
+	// Each else-if is a block holding the next if expression.
+	for _, expected := range []struct {
+		operand int
+		branch  string
+	}{{1, "a"}, {2, "b"}, {3, "c"}} {
+		if !testInfixExpression(t, exp.Condition, "x", "<", expected.operand) {
+			return
+		}
+		consequence := exp.Consequence.Statements[0].(*ast.ExpressionStatement)
+		if !testIdentifier(t, consequence.Expression, expected.branch) {
+			return
+		}
+		if expected.operand == 3 {
+			break
+		}
+
+		if len(exp.Alternative.Statements) != 1 {
+			t.Fatalf("else-if block does not contain 1 statement. got=%d",
+				len(exp.Alternative.Statements))
+		}
+		nested := exp.Alternative.Statements[0].(*ast.ExpressionStatement)
+		exp, ok = nested.Expression.(*ast.IfExpression)
+		if !ok {
+			t.Fatalf("else-if block does not hold an IfExpression. got=%T",
+				nested.Expression)
+		}
+	}
+
+	alternative := exp.Alternative.Statements[0].(*ast.ExpressionStatement)
+	testIdentifier(t, alternative.Expression, "d")
+
+	if stmt.Expression.End().String() != "1:72" {
+		t.Errorf("wrong end position. want=1:72, got=%s", stmt.Expression.End())
+	}
+}
+
 func TestFunctionLiteralParsing(t *testing.T) {
 	input := `fn(x, y) { x + y; }`
 
@@ -870,6 +1088,79 @@ func TestStringLiteralExpression(t *testing.T) {
 		t.Errorf("literal.Value not %q. got=%q", "hello world", literal.Value)
 	}
 }
+func TestInterpolatedStringParsing(t *testing.T) {
+	input := `"sum: ${a + b}, ${f(x)}!"`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	str, ok := stmt.Expression.(*ast.InterpolatedString)
+	if !ok {
+		t.Fatalf("exp not *ast.InterpolatedString. got=%T", stmt.Expression)
+	}
+
+	if len(str.Parts) != 5 {
+		t.Fatalf("wrong number of parts. want=5, got=%d", len(str.Parts))
+	}
+	for i, text := range []string{"sum: ", ", ", "!"} {
+		literal, ok := str.Parts[2*i].(*ast.StringLiteral)
+		if !ok || literal.Value != text {
+			t.Errorf("parts[%d] is not text %q. got=%T (%s)",
+				2*i, text, str.Parts[2*i], str.Parts[2*i])
+		}
+	}
+	testInfixExpression(t, str.Parts[1], "a", "+", "b")
+
+	expected := `"sum: ${(a + b)}, ${f(x)}!"`
+	if program.String() != expected {
+		t.Errorf("wrong String(). want=%q, got=%q", expected, program.String())
+	}
+	if str.End().String() != "1:26" {
+		t.Errorf("wrong end position. want=1:26, got=%s", str.End())
+	}
+}
+
+func TestProgramComments(t *testing.T) {
+	input := `// add two numbers
+let add = fn(a, b) { a + b /* no overflow check */ };`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain 1 statements. got=%d",
+			len(program.Statements))
+	}
+
+	expected := []struct {
+		text string
+		line int
+	}{
+		{" add two numbers", 1},
+		{" no overflow check ", 2},
+	}
+	if len(program.Comments) != len(expected) {
+		t.Fatalf("wrong number of comments. want=%d, got=%d",
+			len(expected), len(program.Comments))
+	}
+	for i, tt := range expected {
+		comment := program.Comments[i]
+		if comment.Text() != tt.text {
+			t.Errorf("comments[%d].Text() wrong. want=%q, got=%q",
+				i, tt.text, comment.Text())
+		}
+		if comment.Pos().Line != tt.line {
+			t.Errorf("comments[%d] on wrong line. want=%d, got=%d",
+				i, tt.line, comment.Pos().Line)
+		}
+	}
+}
+
 func TestParsingArrayLiterals(t *testing.T) {
 	input := "[1, 2 * 2, 3 + 3]"
 
@@ -892,6 +1183,333 @@ func TestParsingArrayLiterals(t *testing.T) {
 	testInfixExpression(t, array.Elements[2], 3, "+", 3)
 }
 
+func TestNodePositions(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedStart string
+		expectedEnd   string
+	}{
+		{"foobar;", "1:1", "1:7"},
+		{"let x = 5 * 10;", "1:1", "1:15"},
+		{"return add(1, 2);", "1:1", "1:17"},
+		{"a + b\n  * c", "1:1", "2:6"},
+		{"fn(x) {\n  x\n}", "1:1", "3:2"},
+		{"if (x) { 1 } else { 2 }", "1:1", "1:24"},
+		{"[1, 2][0]", "1:1", "1:10"},
+		{`{"a": 1}`, "1:1", "1:9"},
+		{"-x", "1:1", "1:3"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statements. got=%d",
+				len(program.Statements))
+		}
+
+		stmt := program.Statements[0]
+		if stmt.Pos().String() != tt.expectedStart {
+			t.Errorf("%q: wrong start position. want=%s, got=%s",
+				tt.input, tt.expectedStart, stmt.Pos())
+		}
+		if stmt.End().String() != tt.expectedEnd {
+			t.Errorf("%q: wrong end position. want=%s, got=%s",
+				tt.input, tt.expectedEnd, stmt.End())
+		}
+	}
+}
+
+func TestParserErrors(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedErrors []string
+	}{
+		{"let = 5;", []string{"1:5: expected identifier but got `=`"}},
+		{"let x 5;", []string{"1:7: expected `=` but got integer `5`"}},
+		{"add(1, 2", []string{"1:9: expected `)` but got end of input"}},
+		{"if (x { 1 }", []string{"1:7: expected `)` but got `{`"}},
+		{"fn(1) { 1 }", []string{"1:4: expected identifier but got integer `1`"}},
+		{"let x = ;", []string{"1:9: unexpected `;`"}},
+		{"let x = 1 @ 2;", []string{"1:11: unexpected character `@`"}},
+		{"let s = \"abc", []string{"1:9: unterminated string literal"}},
+		{`"${}"`, []string{"1:4: unexpected `}`"}},
+		{`"${a b}"`, []string{"1:6: expected `}` but got identifier `b`"}},
+		{`let s = "\q"; let t = 1`, []string{"1:9: unknown escape sequence `\\q`"}},
+		{"99999999999999999999", []string{`1:1: could not parse "99999999999999999999" as integer`}},
+		{
+			"let = 1; let y = 2; let z 3; y",
+			[]string{
+				"1:5: expected identifier but got `=`",
+				"1:27: expected `=` but got integer `3`",
+			},
+		},
+		{
+			"let f = fn(x) { let = x; x };\nlet g = (1 + 2;",
+			[]string{
+				"1:21: expected identifier but got `=`",
+				"2:15: expected `)` but got `;`",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != len(tt.expectedErrors) {
+			t.Errorf("%q: wrong number of errors. want=%d, got=%d (%v)",
+				tt.input, len(tt.expectedErrors), len(errors), errors)
+			continue
+		}
+
+		for i, msg := range tt.expectedErrors {
+			if errors[i].Error() != msg {
+				t.Errorf("%q: wrong error %d. want=%q, got=%q",
+					tt.input, i, msg, errors[i].Error())
+			}
+		}
+	}
+}
+
+func TestParserRecovery(t *testing.T) {
+	input := "let a = 1; let = 2; let b = 3; a + b"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+
+	if len(p.Errors()) != 1 {
+		t.Fatalf("expected 1 error. got=%d (%v)", len(p.Errors()), p.Errors())
+	}
+
+	expected := "let a = 1;let b = 3;(a + b)"
+	if program.String() != expected {
+		t.Errorf("program after recovery wrong. want=%q, got=%q",
+			expected, program.String())
+	}
+
+	// A `}` outside of any block closes nothing, so it is skipped with
+	// the rest of the broken statement instead of being reported again.
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"add(1, 2}", []string{"1:9: expected `)` but got `}`"}},
+		{"add(1, 2}; let c = 3", []string{"1:9: expected `)` but got `}`"}},
+		{"add(1, 2} }", []string{"1:9: expected `)` but got `}`"}},
+		{"let f = fn() { add(1, 2 }; let = 1", []string{
+			"1:25: expected `)` but got `}`",
+			"1:32: expected identifier but got `=`",
+		}},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+
+		errs := p.Errors()
+		if len(errs) != len(tt.expected) {
+			t.Errorf("%q: expected %d errors. got=%d (%v)",
+				tt.input, len(tt.expected), len(errs), errs)
+			continue
+		}
+		for i, err := range errs {
+			if err.Error() != tt.expected[i] {
+				t.Errorf("%q: error %d wrong. want=%q, got=%q",
+					tt.input, i, tt.expected[i], err.Error())
+			}
+		}
+	}
+}
+
+func TestErrorListError(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let = 1", "1:5: expected identifier but got `=`"},
+		{"let = 1; let = 2", "1:5: expected identifier but got `=` (and 1 more error)"},
+		{"let = 1; let = 2; let = 3", "1:5: expected identifier but got `=` (and 2 more errors)"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+
+		if got := p.Errors().Error(); got != tt.expected {
+			t.Errorf("%q: want=%q, got=%q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestAssignExpressions(t *testing.T) {
+	tests := []struct {
+		input            string
+		expectedTarget   string
+		expectedOperator string
+		expectedValue    string
+	}{
+		{"x = 5;", "x", "=", "5"},
+		{"x += y * 2;", "x", "+=", "(y * 2)"},
+		{"x -= 1;", "x", "-=", "1"},
+		{"x *= 3;", "x", "*=", "3"},
+		{"x /= 4;", "x", "/=", "4"},
+		{`h["k"] = true;`, `(h[k])`, "=", "true"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		exp, ok := stmt.Expression.(*ast.AssignExpression)
+		if !ok {
+			t.Fatalf("exp not *ast.AssignExpression. got=%T", stmt.Expression)
+		}
+
+		if exp.Target.String() != tt.expectedTarget {
+			t.Errorf("wrong target. want=%q, got=%q", tt.expectedTarget, exp.Target)
+		}
+		if exp.Operator != tt.expectedOperator {
+			t.Errorf("wrong operator. want=%q, got=%q", tt.expectedOperator, exp.Operator)
+		}
+		if exp.Value.String() != tt.expectedValue {
+			t.Errorf("wrong value. want=%q, got=%q", tt.expectedValue, exp.Value)
+		}
+	}
+}
+
+func TestInvalidAssignmentTarget(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{"1 = 2", "1:1: cannot assign to 1"},
+		{"a + b = c", "1:1: cannot assign to (a + b)"},
+		{"f() += 1", "1:1: cannot assign to f()"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != 1 {
+			t.Errorf("%q: expected 1 error. got=%d (%v)", tt.input, len(errors), errors)
+			continue
+		}
+		if errors[0].Error() != tt.expectedError {
+			t.Errorf("%q: wrong error. want=%q, got=%q",
+				tt.input, tt.expectedError, errors[0].Error())
+		}
+	}
+}
+
+func TestLoopStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			"while (x < 10) { x }",
+			"while ((x < 10)) { x }",
+		},
+		{
+			"for (x in [1, 2]) { break; }",
+			"for (x in [1, 2]) { break; }",
+		},
+		{
+			"for (let i = 0; i < 3; let i = i + 1) { continue }",
+			"for (let i = 0; (i < 3); let i = (i + 1)) { continue; }",
+		},
+		{
+			"for (;;) { break }",
+			"for (; ; ) { break; }",
+		},
+		{
+			"while (true) { for (c in s) { continue } }",
+			"while (true) { for (c in s) { continue; } }",
+		},
+		{
+			"while (x < 10) { x };",
+			"while ((x < 10)) { x }",
+		},
+		{
+			"for (x in [1, 2]) { x };",
+			"for (x in [1, 2]) { x }",
+		},
+		{
+			"for (;;) { break };",
+			"for (; ; ) { break; }",
+		},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statements. got=%d",
+				len(program.Statements))
+		}
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+}
+
+func TestLoopControlOutsideLoop(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{"break;", "1:1: `break` outside of a loop"},
+		{"if (true) { continue }", "1:13: `continue` outside of a loop"},
+		{"while (true) { fn() { break } }", "1:23: `break` outside of a loop"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != 1 {
+			t.Errorf("%q: expected 1 error. got=%d (%v)",
+				tt.input, len(errors), errors)
+			continue
+		}
+		if errors[0].Error() != tt.expectedError {
+			t.Errorf("%q: wrong error. want=%q, got=%q",
+				tt.input, tt.expectedError, errors[0].Error())
+		}
+	}
+}
+
+func checkParserErrors(t *testing.T, p *Parser) {
+	errors := p.Errors()
+	if len(errors) == 0 {
+		return
+	}
+
+	t.Errorf("parser has %d errors", len(errors))
+	for _, err := range errors {
+		t.Errorf("parser error: %s", err)
+	}
+	t.FailNow()
+}
+
 func testLetStatement(t *testing.T, s ast.Statement, name string) bool {
 	if s.TokenLiteral() != "let" {
 		t.Errorf("s.TokenLiteral not 'let'. got=%q", s.TokenLiteral())
@@ -1023,3 +1641,83 @@ func testBooleanLiteral(t *testing.T, exp ast.Expression, value bool) bool {
 
 	return true
 }
+
+func TestTailCalls(t *testing.T) {
+	// Calls to functions named t... are in tail position, n... are not.
+	input := `
+let f = fn(x) {
+  n1(x);
+  if (x) { return t1(n2(x)) }
+  while (x) { n3(); return t2() }
+  let y = n4();
+  if (x) { n5(); t3() } else if (y) { t4() } else { 1 + n6() }
+};
+let g = fn() { return t5() };
+let h = fn() { let k = fn() { t6() }; k()?[0]; n10(n7)(n8()) };
+n9()
+`
+
+	p := New(lexer.New(input))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	calls := map[string]bool{}
+	collectCalls(program, calls)
+
+	for name, tail := range calls {
+		if tail != strings.HasPrefix(name, "t") {
+			t.Errorf("call to %s: expected Tail=%t, got=%t",
+				name, strings.HasPrefix(name, "t"), tail)
+		}
+	}
+	if len(calls) < 15 {
+		t.Errorf("expected at least 15 calls, found %d: %v", len(calls), calls)
+	}
+}
+
+// collectCalls records whether each call in node to a named function
+// is a tail call.
+func collectCalls(node ast.Node, calls map[string]bool) {
+
+	switch node := node.(type) {
+	case *ast.Program:
+		for _, stmt := range node.Statements {
+			collectCalls(stmt, calls)
+		}
+	case *ast.BlockStatement:
+		for _, stmt := range node.Statements {
+			collectCalls(stmt, calls)
+		}
+	case *ast.LetStatement:
+		collectCalls(node.Value, calls)
+	case *ast.ReturnStatement:
+		collectCalls(node.ReturnValue, calls)
+	case *ast.ExpressionStatement:
+		collectCalls(node.Expression, calls)
+	case *ast.WhileStatement:
+		collectCalls(node.Condition, calls)
+		collectCalls(node.Body, calls)
+	case *ast.IfExpression:
+		collectCalls(node.Condition, calls)
+		collectCalls(node.Consequence, calls)
+		if node.Alternative != nil {
+			collectCalls(node.Alternative, calls)
+		}
+	case *ast.InfixExpression:
+		collectCalls(node.Left, calls)
+		collectCalls(node.Right, calls)
+	case *ast.FunctionLiteral:
+		collectCalls(node.Body, calls)
+	case *ast.IndexExpression:
+		collectCalls(node.Left, calls)
+	case *ast.CallExpression:
+		if ident, ok := node.Function.(*ast.Identifier); ok {
+			calls[ident.Value] = node.Tail
+		} else {
+			collectCalls(node.Function, calls)
+		}
+		for _, arg := range node.Arguments {
+			collectCalls(arg, calls)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package parser
+
+import "camel/ast"
+
+// markTailCalls sets Tail on the calls in body whose value becomes the
+// value of the function: the value of a return statement, and the last
+// expression of the body, looking into both branches of an if.
+func markTailCalls(body *ast.BlockStatement) {
+	markTailBlock(body, true)
+}
+
+// markTailBlock marks the returned calls of block, and its last
+// expression too when the block's value is the function's.
+func markTailBlock(block *ast.BlockStatement, tail bool) {
+
+	if block == nil {
+		return
+	}
+
+	for i, stmt := range block.Statements {
+		last := tail && i == len(block.Statements)-1
+
+		switch stmt := stmt.(type) {
+		case *ast.ReturnStatement:
+			markTailExpression(stmt.ReturnValue, true)
+		case *ast.ExpressionStatement:
+			markTailExpression(stmt.Expression, last)
+
+		// A loop's body is never in tail position, but the return
+		// statements in it are.
+		case *ast.WhileStatement:
+			markTailBlock(stmt.Body, false)
+		case *ast.ForStatement:
+			markTailBlock(stmt.Body, false)
+		case *ast.ForInStatement:
+			markTailBlock(stmt.Body, false)
+		}
+	}
+}
+
+func markTailExpression(expr ast.Expression, tail bool) {
+
+	switch expr := expr.(type) {
+	case *ast.CallExpression:
+		expr.Tail = tail
+	case *ast.IfExpression:
+		markTailBlock(expr.Consequence, tail)
+		markTailBlock(expr.Alternative, tail)
+	}
+}
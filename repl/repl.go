@@ -6,6 +6,7 @@ import (
 	"camel/lexer"
 	"camel/object"
 	"camel/parser"
+	"camel/token"
 	"fmt"
 	"io"
 )
@@ -30,27 +31,47 @@ https://asciiart.website/index.php?art=animals/camels
 
 const PROMPT = ">> "
 
-func Start(in io.Reader, out io.Writer) {
+// Start reads lines from in and prints the value of each to out, until
+// in is exhausted or a line calls exit(), and returns the exit status
+// for the session. Keywords are read in the given dialect; nil selects
+// English.
+func Start(in io.Reader, out io.Writer, dialect *token.Dialect) int {
 
 	scanner := bufio.NewScanner(in)
 	env := object.NewEnvironment()
+	evaluator := eval.New()
 
 	for {
 
 		fmt.Printf(PROMPT)
 		scanned := scanner.Scan()
 		if !scanned {
-			return
+			return 0
 		}
 
 		line := scanner.Text()
 		lex := lexer.New(line)
+		lex.SetDialect(dialect)
 		parser := parser.New(lex)
 		program := parser.ParseProgram()
-		evaluated := eval.Eval(program, env)
+		if errs := parser.Errors(); len(errs) > 0 {
+			printParserErrors(out, errs)
+			continue
+		}
+
+		evaluated := evaluator.Eval(program, env)
+		if err, ok := evaluated.(*object.Error); ok && err.Kind == object.EXIT_ERROR {
+			return err.Code
+		}
 		if evaluated != nil {
 			io.WriteString(out, evaluated.Inspect())
 			io.WriteString(out, "\n")
 		}
 	}
 }
+
+func printParserErrors(out io.Writer, errs parser.ErrorList) {
+	for _, err := range errs {
+		io.WriteString(out, "Syntax error: "+err.Error()+"\n")
+	}
+}
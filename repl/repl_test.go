@@ -0,0 +1,33 @@
+package repl
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestStartExit(t *testing.T) {
+	tests := []struct {
+		input    string
+		code     int
+		expected string
+	}{
+		{"1 + 1\n", 0, "2\n"},
+		{"1 + 1\nexit(2)\n3\n", 2, "2\n"},
+		{"exit()\n", 0, ""},
+		{"let f = fn() { exit(7) }\nf()\n", 7, ""},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		code := Start(strings.NewReader(tt.input), &out, nil)
+		if code != tt.code {
+			t.Errorf("%q: wrong exit status. expected=%d, got=%d",
+				tt.input, tt.code, code)
+		}
+		if out.String() != tt.expected {
+			t.Errorf("%q: wrong output. expected=%q, got=%q",
+				tt.input, tt.expected, out.String())
+		}
+	}
+}
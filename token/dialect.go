@@ -0,0 +1,107 @@
+package token
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Dialect is a set of words for the keywords of the language. A
+// dialect renames some keywords; the others keep their English
+// spelling. Only the lexer consults the dialect, so the rest of the
+// interpreter works the same whatever words the program is written in.
+type Dialect struct {
+	Name     string
+	keywords map[string]TokenType
+}
+
+// English is the default dialect.
+var English = &Dialect{Name: "english", keywords: keywords}
+
+// Persian is the dialect used in the README: `beza` declares a
+// variable, `bede` returns, and functions are written with either
+// `foo` or `bar`.
+var Persian = mustDialect("persian", map[string]string{
+	"beza": "let",
+	"bede": "return",
+	"foo":  "fn",
+	"bar":  "fn",
+})
+
+// Dialects holds the built-in dialects by name.
+var Dialects = map[string]*Dialect{
+	English.Name: English,
+	Persian.Name: Persian,
+}
+
+// NewDialect returns a dialect named name. words maps each new word to
+// the English keyword it stands for, such as "soit": "let". Several
+// words may stand for the same keyword, and a renamed keyword can no
+// longer be written in English.
+func NewDialect(name string, words map[string]string) (*Dialect, error) {
+
+	d := &Dialect{Name: name, keywords: map[string]TokenType{}}
+
+	renamed := map[string]bool{}
+	for word, english := range words {
+		keyword, ok := keywords[english]
+		if !ok {
+			return nil, fmt.Errorf("dialect %s: %q is not a keyword", name, english)
+		}
+		if word == "" {
+			return nil, fmt.Errorf("dialect %s: empty word for %q", name, english)
+		}
+		d.keywords[word] = keyword
+		renamed[english] = true
+	}
+
+	for english, keyword := range keywords {
+		if renamed[english] {
+			continue
+		}
+		if _, taken := d.keywords[english]; taken {
+			return nil, fmt.Errorf("dialect %s: %q is already the keyword %q",
+				name, english, english)
+		}
+		d.keywords[english] = keyword
+	}
+	return d, nil
+}
+
+func mustDialect(name string, words map[string]string) *Dialect {
+	d, err := NewDialect(name, words)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// ParseDialect reads a dialect from JSON of the form
+//
+//	{"name": "french", "keywords": {"soit": "let", "fonction": "fn"}}
+//
+// where keywords maps each word to the English keyword it replaces.
+// The name is optional and defaults to "custom".
+func ParseDialect(data []byte) (*Dialect, error) {
+
+	var spec struct {
+		Name     string            `json:"name"`
+		Keywords map[string]string `json:"keywords"`
+	}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("dialect: %w", err)
+	}
+	if spec.Name == "" {
+		spec.Name = "custom"
+	}
+	return NewDialect(spec.Name, spec.Keywords)
+}
+
+// LookUpIdent returns the keyword type of ident in the dialect, or
+// IDENT when it isn't a keyword.
+func (d *Dialect) LookUpIdent(ident string) TokenType {
+
+	if keyword, ok := d.keywords[ident]; ok {
+		return keyword
+	}
+	return IDENT
+}
@@ -1,20 +1,61 @@
 package token
 
+import "fmt"
+
 type TokenType string
 
+// Position describes a location in a source file. Line and Column are
+// 1-based, Offset is the 0-based byte offset into the input. Column
+// counts characters, not bytes.
+type Position struct {
+	Filename string
+	Offset   int
+	Line     int
+	Column   int
+}
+
+// IsValid reports whether the position has been set.
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+// String returns the position as "file:line:column", leaving out the
+// file name when there is none and returning "-" for an unset position.
+func (p Position) String() string {
+	if !p.IsValid() {
+		return "-"
+	}
+	if p.Filename != "" {
+		return fmt.Sprintf("%s:%d:%d", p.Filename, p.Line, p.Column)
+	}
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+// Token is a lexeme together with its source span. Pos is where the
+// token starts and End is the position just past its last character.
 type Token struct {
 	Type    TokenType
 	Literal string
+	Pos     Position
+	End     Position
 }
 
 const (
 	ILLEGAL = "ILLEGAL"
+	COMMENT = "COMMENT"
 	EOF     = "EOF"
 
 	INT    = "INT"
+	FLOAT  = "FLOAT"
 	STRING = "STRING"
 	IDENT  = "IDENT"
 
+	// An interpolated string "a ${x} b ${y} c" is lexed as
+	// INTERP_START("a "), x, INTERP_MID(" b "), y, INTERP_END(" c").
+	INTERP_START = "INTERP_START"
+	INTERP_MID   = "INTERP_MID"
+	INTERP_END   = "INTERP_END"
+
 	PLUS     = "+"
 	MINUS    = "-"
 	BANG     = "!"
@@ -24,8 +65,38 @@ const (
 	EQ       = "=="
 	NOT_EQ   = "!="
 
-	LT = "<"
-	GT = ">"
+	PERCENT     = "%"
+	POWER       = "**"
+	BIT_AND     = "&"
+	BIT_OR      = "|"
+	BIT_XOR     = "^"
+	BIT_NOT     = "~"
+	SHIFT_LEFT  = "<<"
+	SHIFT_RIGHT = ">>"
+
+	PLUS_ASSIGN        = "+="
+	MINUS_ASSIGN       = "-="
+	ASTERISK_ASSIGN    = "*="
+	SLASH_ASSIGN       = "/="
+	PERCENT_ASSIGN     = "%="
+	POWER_ASSIGN       = "**="
+	BIT_AND_ASSIGN     = "&="
+	BIT_OR_ASSIGN      = "|="
+	BIT_XOR_ASSIGN     = "^="
+	SHIFT_LEFT_ASSIGN  = "<<="
+	SHIFT_RIGHT_ASSIGN = ">>="
+
+	LT    = "<"
+	GT    = ">"
+	LT_EQ = "<="
+	GT_EQ = ">="
+
+	AND = "&&"
+	OR  = "||"
+
+	NULLISH        = "??"
+	OPTIONAL_INDEX = "?["
+	OPTIONAL_CALL  = "?("
 
 	COMMA     = ","
 	SEMICOLON = ";"
@@ -45,7 +116,14 @@ const (
 	ELSE   = "else"
 	TRUE   = "true"
 	FALSE  = "false"
+	NULL   = "null"
 	RETURN = "return"
+
+	WHILE    = "while"
+	FOR      = "for"
+	IN       = "in"
+	BREAK    = "break"
+	CONTINUE = "continue"
 )
 
 var keywords = map[string]TokenType{
@@ -55,13 +133,18 @@ var keywords = map[string]TokenType{
 	"else":   ELSE,
 	"true":   TRUE,
 	"false":  FALSE,
+	"null":   NULL,
 	"return": RETURN,
+
+	"while":    WHILE,
+	"for":      FOR,
+	"in":       IN,
+	"break":    BREAK,
+	"continue": CONTINUE,
 }
 
+// LookUpIdent returns the keyword type of ident in the English
+// dialect, or IDENT when it isn't a keyword.
 func LookUpIdent(ident string) TokenType {
-
-	if keyword, ok := keywords[ident]; ok {
-		return keyword
-	}
-	return IDENT
+	return English.LookUpIdent(ident)
 }